@@ -0,0 +1,37 @@
+package temboplus
+
+import "context"
+
+// API describes the operations offered by Client. Depend on API rather than
+// *Client so a fake (see the temboplustest package) can be substituted in
+// unit tests.
+type API interface {
+	// Collections
+	CollectFromMobileMoney(ctx context.Context, req MobileMoneyCollectionRequest) (*MobileMoneyCollectionResponse, error)
+	GetCollectionStatus(ctx context.Context, req PaymentStatusRequest) (*MobileMoneyCollectionResponse, error)
+
+	// Payouts
+	PayWalletToMobile(ctx context.Context, req WalletToMobileRequest) (*MobileMoneyCollectionResponse, error)
+	PayWalletToBank(ctx context.Context, req WalletToMobileRequest) (*MobileMoneyCollectionResponse, error)
+	PayToBankAccount(ctx context.Context, req BankPayoutRequest) (*MobileMoneyCollectionResponse, error)
+	GetPaymentStatus(ctx context.Context, req PaymentStatusRequest) (*MobileMoneyCollectionResponse, error)
+	Refund(ctx context.Context, req RefundRequest) (*MobileMoneyCollectionResponse, error)
+
+	// Balances
+	GetCollectionBalance(ctx context.Context) (*CollectionBalanceResponse, error)
+	GetMainBalance(ctx context.Context) (*CollectionBalanceResponse, error)
+	GetWalletBalance(ctx context.Context, accountNo string) (*CollectionBalanceResponse, error)
+
+	// Statements
+	GetCollectionStatement(ctx context.Context, reqBody CollectionStatementRequest) ([]CollectionStatementEntry, error)
+	GetMainStatement(ctx context.Context, reqBody CollectionStatementRequest) ([]CollectionStatementEntry, error)
+
+	// Wallets
+	ListWallets(ctx context.Context) ([]Wallet, error)
+
+	// Webhooks
+	ValidateWebhook(payload []byte) (*WebhookPayload, error)
+}
+
+// Ensure Client implements API
+var _ API = (*Client)(nil)
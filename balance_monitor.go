@@ -0,0 +1,371 @@
+package temboplus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// BalanceSource identifies which balance endpoint a monitored target uses
+type BalanceSource string
+
+const (
+	BalanceSourceMain       BalanceSource = "main"
+	BalanceSourceCollection BalanceSource = "collection"
+	BalanceSourceWallet     BalanceSource = "wallet"
+)
+
+// BalanceTarget is an account watched by a BalanceMonitor
+type BalanceTarget struct {
+	Name       string        // Label used in events; defaults to the source or account number
+	Source     BalanceSource // main, collection or wallet
+	AccountNo  string        // Required for wallet targets
+	Thresholds []float64     // Overrides BalanceMonitorConfig.Thresholds for this target
+}
+
+// MonitorMain returns a target for the main account balance
+func MonitorMain(thresholds ...float64) BalanceTarget {
+	return BalanceTarget{Name: "main", Source: BalanceSourceMain, Thresholds: thresholds}
+}
+
+// MonitorCollection returns a target for the collection account balance
+func MonitorCollection(thresholds ...float64) BalanceTarget {
+	return BalanceTarget{Name: "collection", Source: BalanceSourceCollection, Thresholds: thresholds}
+}
+
+// MonitorWallet returns a target for a wallet balance
+func MonitorWallet(accountNo string, thresholds ...float64) BalanceTarget {
+	return BalanceTarget{Name: accountNo, Source: BalanceSourceWallet, AccountNo: accountNo, Thresholds: thresholds}
+}
+
+// BalanceEventType classifies balance events
+type BalanceEventType string
+
+const (
+	// BalanceEventLow fires when the available balance drops below a threshold
+	BalanceEventLow BalanceEventType = "BALANCE_LOW"
+	// BalanceEventRecovered fires when the available balance rises back to or above a threshold
+	BalanceEventRecovered BalanceEventType = "BALANCE_RECOVERED"
+	// BalanceEventChanged fires when the balance moves by at least ChangeThreshold between polls
+	BalanceEventChanged BalanceEventType = "BALANCE_CHANGED"
+	// BalanceEventCheckFailed fires when a balance could not be fetched,
+	// once per run of failed checks
+	BalanceEventCheckFailed BalanceEventType = "BALANCE_CHECK_FAILED"
+)
+
+// BalanceEvent is emitted by a BalanceMonitor
+type BalanceEvent struct {
+	Type      BalanceEventType           `json:"type"`
+	Target    string                     `json:"target"`
+	AccountNo string                     `json:"accountNo,omitempty"`
+	Threshold float64                    `json:"threshold,omitempty"`
+	Previous  float64                    `json:"previous"`
+	Current   float64                    `json:"current"`
+	Balance   *CollectionBalanceResponse `json:"balance,omitempty"`
+	Error     string                     `json:"error,omitempty"`
+	Time      time.Time                  `json:"time"`
+}
+
+// String returns a human readable description of the event
+func (e BalanceEvent) String() string {
+	switch e.Type {
+	case BalanceEventLow:
+		return fmt.Sprintf("%s balance %.2f is below %.2f", e.Target, e.Current, e.Threshold)
+	case BalanceEventRecovered:
+		return fmt.Sprintf("%s balance %.2f is back above %.2f", e.Target, e.Current, e.Threshold)
+	case BalanceEventChanged:
+		return fmt.Sprintf("%s balance changed from %.2f to %.2f", e.Target, e.Previous, e.Current)
+	case BalanceEventCheckFailed:
+		return fmt.Sprintf("%s balance check failed: %s", e.Target, e.Error)
+	default:
+		return fmt.Sprintf("%s %s", e.Target, e.Type)
+	}
+}
+
+// BalanceNotifier receives balance events
+type BalanceNotifier interface {
+	Notify(ctx context.Context, event BalanceEvent) error
+}
+
+// BalanceNotifierFunc adapts a callback to BalanceNotifier
+type BalanceNotifierFunc func(ctx context.Context, event BalanceEvent) error
+
+// Notify implements BalanceNotifier
+func (f BalanceNotifierFunc) Notify(ctx context.Context, event BalanceEvent) error {
+	return f(ctx, event)
+}
+
+// LogNotifier writes events to a logger (log.Default() when nil)
+type LogNotifier struct {
+	Logger *log.Logger
+}
+
+// Notify implements BalanceNotifier
+func (n LogNotifier) Notify(ctx context.Context, event BalanceEvent) error {
+	logger := n.Logger
+	if logger == nil {
+		logger = log.Default()
+	}
+	logger.Printf("temboplus: %s", event)
+	return nil
+}
+
+// WebhookNotifier POSTs each event as JSON to URL
+type WebhookNotifier struct {
+	URL        string
+	HTTPClient *http.Client // Default: 10 second timeout
+	Header     http.Header  // Extra headers, e.g. authorization
+}
+
+// Notify implements BalanceNotifier
+func (n WebhookNotifier) Notify(ctx context.Context, event BalanceEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range n.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := n.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// BalanceMonitorConfig configures a BalanceMonitor
+type BalanceMonitorConfig struct {
+	Interval        time.Duration     // Poll interval. Default: 1 minute
+	Targets         []BalanceTarget   // Default: main and collection
+	Thresholds      []float64         // Low-balance levels applied to targets without their own
+	ChangeThreshold float64           // Report moves of at least this much between polls; 0 disables
+	Notifiers       []BalanceNotifier // Receive every event
+	ErrorLog        *log.Logger       // Notifier failures; log.Default() when nil
+}
+
+// BalanceMonitor polls balances and notifies on threshold crossings and
+// unexpected changes
+type BalanceMonitor struct {
+	client API
+	cfg    BalanceMonitorConfig
+
+	mu     sync.Mutex
+	state  map[string]*balanceState
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type balanceState struct {
+	seen    bool
+	failing bool // the last check failed
+	current float64
+	below   map[float64]bool
+	balance *CollectionBalanceResponse
+}
+
+// NewBalanceMonitor creates a monitor; call Start or Run to begin polling
+func NewBalanceMonitor(client API, cfg BalanceMonitorConfig) *BalanceMonitor {
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+	if len(cfg.Targets) == 0 {
+		cfg.Targets = []BalanceTarget{MonitorMain(), MonitorCollection()}
+	} else {
+		// Defaults are filled in below; leave the caller's slice alone
+		cfg.Targets = append([]BalanceTarget(nil), cfg.Targets...)
+	}
+	for i, t := range cfg.Targets {
+		if t.Name == "" {
+			t.Name = string(t.Source)
+			if t.Source == BalanceSourceWallet {
+				t.Name = t.AccountNo
+			}
+		}
+		cfg.Targets[i] = t
+	}
+	return &BalanceMonitor{client: client, cfg: cfg, state: make(map[string]*balanceState)}
+}
+
+// Run polls immediately and then every Interval until ctx is done
+func (m *BalanceMonitor) Run(ctx context.Context) error {
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		m.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Start runs the monitor in the background until Stop is called or ctx is done
+func (m *BalanceMonitor) Start(ctx context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cancel != nil {
+		return
+	}
+	ctx, m.cancel = context.WithCancel(ctx)
+	m.done = make(chan struct{})
+	go func(done chan struct{}) {
+		defer close(done)
+		m.Run(ctx)
+	}(m.done)
+}
+
+// Stop stops a monitor started with Start and waits for it to finish
+func (m *BalanceMonitor) Stop() {
+	m.mu.Lock()
+	cancel, done := m.cancel, m.done
+	m.cancel, m.done = nil, nil
+	m.mu.Unlock()
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// Check polls every target once and dispatches the resulting events, which
+// are also returned
+func (m *BalanceMonitor) Check(ctx context.Context) []BalanceEvent {
+	var events []BalanceEvent
+	for _, target := range m.cfg.Targets {
+		events = append(events, m.checkTarget(ctx, target)...)
+	}
+	for _, event := range events {
+		m.dispatch(ctx, event)
+	}
+	return events
+}
+
+// Last returns the most recent balance fetched for the named target
+func (m *BalanceMonitor) Last(target string) (*CollectionBalanceResponse, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.state[target]
+	if !ok || s.balance == nil {
+		return nil, false
+	}
+	b := *s.balance
+	return &b, true
+}
+
+func (m *BalanceMonitor) checkTarget(ctx context.Context, target BalanceTarget) []BalanceEvent {
+	now := time.Now()
+	balance, err := m.fetch(ctx, target)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.state[target.Name]
+	if !ok {
+		s = &balanceState{below: make(map[float64]bool)}
+		m.state[target.Name] = s
+	}
+	if err != nil {
+		if s.failing {
+			return nil
+		}
+		s.failing = true
+		return []BalanceEvent{{
+			Type:      BalanceEventCheckFailed,
+			Target:    target.Name,
+			AccountNo: target.AccountNo,
+			Error:     err.Error(),
+			Time:      now,
+		}}
+	}
+	s.failing = false
+	previous, current := s.current, balance.AvailableBalance
+	base := BalanceEvent{
+		Target:    target.Name,
+		AccountNo: balance.AccountNo,
+		Previous:  previous,
+		Current:   current,
+		Balance:   balance,
+		Time:      now,
+	}
+
+	var events []BalanceEvent
+	if s.seen && m.cfg.ChangeThreshold > 0 && math.Abs(current-previous) >= m.cfg.ChangeThreshold {
+		e := base
+		e.Type = BalanceEventChanged
+		events = append(events, e)
+	}
+
+	thresholds := target.Thresholds
+	if thresholds == nil {
+		thresholds = m.cfg.Thresholds
+	}
+	for _, level := range thresholds {
+		below := current < level
+		if below == s.below[level] && s.seen {
+			continue
+		}
+		s.below[level] = below
+		if !below && !s.seen {
+			continue
+		}
+		e := base
+		e.Threshold = level
+		e.Type = BalanceEventRecovered
+		if below {
+			e.Type = BalanceEventLow
+		}
+		events = append(events, e)
+	}
+
+	s.seen = true
+	s.current = current
+	s.balance = balance
+	return events
+}
+
+func (m *BalanceMonitor) fetch(ctx context.Context, target BalanceTarget) (*CollectionBalanceResponse, error) {
+	switch target.Source {
+	case BalanceSourceMain:
+		return m.client.GetMainBalance(ctx)
+	case BalanceSourceCollection:
+		return m.client.GetCollectionBalance(ctx)
+	case BalanceSourceWallet:
+		if target.AccountNo == "" {
+			return nil, fmt.Errorf("accountNo is required for wallet targets")
+		}
+		return m.client.GetWalletBalance(ctx, target.AccountNo)
+	default:
+		return nil, fmt.Errorf("unknown balance source: %s", target.Source)
+	}
+}
+
+func (m *BalanceMonitor) dispatch(ctx context.Context, event BalanceEvent) {
+	for _, n := range m.cfg.Notifiers {
+		if err := n.Notify(ctx, event); err != nil {
+			logger := m.cfg.ErrorLog
+			if logger == nil {
+				logger = log.Default()
+			}
+			logger.Printf("temboplus: balance notifier failed: %v", err)
+		}
+	}
+}
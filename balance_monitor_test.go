@@ -0,0 +1,50 @@
+package temboplus_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	temboplus "github.com/techliana/temboplus-golang-sdk"
+	"github.com/techliana/temboplus-golang-sdk/temboplustest"
+)
+
+func TestBalanceMonitorCheckFailedOncePerStreak(t *testing.T) {
+	fail := true
+	client := &temboplustest.FakeClient{
+		GetMainBalanceFunc: func(ctx context.Context) (*temboplus.CollectionBalanceResponse, error) {
+			if fail {
+				return nil, errors.New("connection refused")
+			}
+			return &temboplus.CollectionBalanceResponse{AvailableBalance: 1000}, nil
+		},
+	}
+	targets := []temboplus.BalanceTarget{{Source: temboplus.BalanceSourceMain}}
+	m := temboplus.NewBalanceMonitor(client, temboplus.BalanceMonitorConfig{Targets: targets})
+	if targets[0].Name != "" {
+		t.Errorf("caller's target was modified: Name = %q", targets[0].Name)
+	}
+
+	steps := []struct {
+		fail bool
+		want int // BALANCE_CHECK_FAILED events
+	}{
+		{true, 1},
+		{true, 0},
+		{true, 0},
+		{false, 0},
+		{true, 1},
+	}
+	for i, st := range steps {
+		fail = st.fail
+		got := 0
+		for _, e := range m.Check(context.Background()) {
+			if e.Type == temboplus.BalanceEventCheckFailed {
+				got++
+			}
+		}
+		if got != st.want {
+			t.Errorf("check %d: %d failure events, want %d", i+1, got, st.want)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package temboplus
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Bank describes a bank reachable through bank payouts
+type Bank struct {
+	BIC            string `json:"bic"`                      // SWIFT/BIC code, e.g. CORUTZTZ
+	Name           string `json:"name"`                     // Display name
+	Country        string `json:"country"`                  // Country code
+	AccountLengths []int  `json:"accountLengths,omitempty"` // Allowed account number lengths; empty accepts 6 to 20 digits
+}
+
+// tanzaniaBanks is the bundled directory of Tanzanian banks. Account number
+// formats are not bundled; register a Bank with AccountLengths to enforce
+// one.
+var tanzaniaBanks = []Bank{
+	{BIC: "CORUTZTZ", Name: "CRDB Bank", Country: "TZ"},
+	{BIC: "NMIBTZTZ", Name: "NMB Bank", Country: "TZ"},
+	{BIC: "NLCBTZTX", Name: "NBC Bank", Country: "TZ"},
+	{BIC: "SBICTZTX", Name: "Stanbic Bank Tanzania", Country: "TZ"},
+	{BIC: "SCBLTZTX", Name: "Standard Chartered Bank Tanzania", Country: "TZ"},
+	{BIC: "EQBLTZTZ", Name: "Equity Bank Tanzania", Country: "TZ"},
+	{BIC: "EXTNTZTZ", Name: "Exim Bank Tanzania", Country: "TZ"},
+	{BIC: "KCBLTZTZ", Name: "KCB Bank Tanzania", Country: "TZ"},
+	{BIC: "DTKETZTZ", Name: "Diamond Trust Bank Tanzania", Country: "TZ"},
+	{BIC: "BARCTZTZ", Name: "Absa Bank Tanzania", Country: "TZ"},
+	{BIC: "EUAFTZTZ", Name: "Bank of Africa Tanzania", Country: "TZ"},
+	{BIC: "AZANTZTZ", Name: "Azania Bank", Country: "TZ"},
+	{BIC: "TAPBTZTZ", Name: "TCB Bank", Country: "TZ"},
+	{BIC: "IMBLTZTZ", Name: "I&M Bank Tanzania", Country: "TZ"},
+	{BIC: "CITITZTZ", Name: "Citibank Tanzania", Country: "TZ"},
+}
+
+// RegisterBank adds or replaces a bank, keyed by BIC
+func (r *Registry) RegisterBank(b Bank) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b.BIC = strings.ToUpper(b.BIC)
+	b.Country = strings.ToUpper(b.Country)
+	b.AccountLengths = append([]int(nil), b.AccountLengths...)
+	r.banks[b.BIC] = b
+}
+
+// Bank looks up a bank by BIC. Eleven-character branch BICs match on their
+// first eight characters, the institution and location code.
+func (r *Registry) Bank(bic string) (Bank, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	bic = strings.ToUpper(strings.TrimSpace(bic))
+	if len(bic) == 11 {
+		bic = bic[:8]
+	}
+	b, ok := r.banks[bic]
+	return b, ok
+}
+
+// Banks returns the banks of a country sorted by name. An empty country
+// matches all countries.
+func (r *Registry) Banks(country string) []Bank {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var banks []Bank
+	for _, b := range r.banks {
+		if country == "" || b.Country == strings.ToUpper(country) {
+			banks = append(banks, b)
+		}
+	}
+	sort.Slice(banks, func(i, j int) bool { return banks[i].Name < banks[j].Name })
+	return banks
+}
+
+// ValidateBankAccount checks that the BIC is known and the account number
+// matches the bank's format. It returns the normalized account number.
+func (r *Registry) ValidateBankAccount(bic, accountNumber string) (string, error) {
+	bank, ok := r.Bank(bic)
+	if !ok {
+		return "", fmt.Errorf("unknown bank BIC: %s", bic)
+	}
+	account := normalizeBankAccount(accountNumber)
+	if account == "" {
+		return "", fmt.Errorf("bank account number is required")
+	}
+	for _, ch := range account {
+		if ch < '0' || ch > '9' {
+			return "", fmt.Errorf("%s account number must contain only digits: %s", bank.Name, accountNumber)
+		}
+	}
+	if len(bank.AccountLengths) == 0 {
+		if len(account) < 6 || len(account) > 20 {
+			return "", fmt.Errorf("%s account number must have 6 to 20 digits: %s", bank.Name, accountNumber)
+		}
+		return account, nil
+	}
+	for _, n := range bank.AccountLengths {
+		if len(account) == n {
+			return account, nil
+		}
+	}
+	return "", fmt.Errorf("%s account number must have %s digits: %s", bank.Name, joinLengths(bank.AccountLengths), accountNumber)
+}
+
+// BankPayoutRequest is a typed wallet-to-bank payout
+type BankPayoutRequest struct {
+	CountryCode     string  // e.g., TZ
+	AccountNo       string  // Source wallet account number
+	ServiceCode     string  // Default: TZ-BANK-B2C
+	BIC             string  // Destination bank BIC, e.g. CORUTZTZ
+	AccountNumber   string  // Destination bank account number
+	AccountHolder   string  // Name on the destination account
+	Amount          float64 // Amount to transfer
+	Narration       string  // Transfer narration
+	CurrencyCode    string  // e.g., TZS
+	TransactionRef  string  // Your system reference
+	TransactionDate string  // Value date
+	CallbackURL     string  // Webhook URL
+}
+
+// WalletToMobileRequest converts the request to the wire format, with
+// MSISDN set to <BIC>:<ACCOUNT NUMBER>
+func (r BankPayoutRequest) WalletToMobileRequest() WalletToMobileRequest {
+	service := r.ServiceCode
+	if service == "" {
+		service = ServiceTZBankB2C
+	}
+	return WalletToMobileRequest{
+		CountryCode:     r.CountryCode,
+		AccountNo:       r.AccountNo,
+		ServiceCode:     service,
+		Amount:          r.Amount,
+		MSISDN:          FormatBankDestination(r.BIC, r.AccountNumber),
+		Narration:       r.Narration,
+		CurrencyCode:    r.CurrencyCode,
+		RecipientNames:  r.AccountHolder,
+		TransactionRef:  r.TransactionRef,
+		TransactionDate: r.TransactionDate,
+		CallbackURL:     r.CallbackURL,
+	}
+}
+
+// FormatBankDestination returns the <BIC>:<ACCOUNT NUMBER> destination
+// used by bank payouts
+func FormatBankDestination(bic, accountNumber string) string {
+	return strings.ToUpper(strings.TrimSpace(bic)) + ":" + normalizeBankAccount(accountNumber)
+}
+
+// ParseBankDestination splits a <BIC>:<ACCOUNT NUMBER> destination
+func ParseBankDestination(destination string) (bic, accountNumber string, err error) {
+	bic, accountNumber, ok := strings.Cut(destination, ":")
+	if !ok || strings.TrimSpace(bic) == "" || strings.TrimSpace(accountNumber) == "" {
+		return "", "", fmt.Errorf("bank destination must be in the format <BIC>:<ACCOUNT NUMBER>: %s", destination)
+	}
+	return strings.ToUpper(strings.TrimSpace(bic)), normalizeBankAccount(accountNumber), nil
+}
+
+// PayToBankAccount validates the destination bank account against the
+// registry and sends the payout through PayWalletToBank
+func (c *Client) PayToBankAccount(ctx context.Context, req BankPayoutRequest) (*MobileMoneyCollectionResponse, error) {
+	if req.AccountHolder == "" {
+		return nil, fmt.Errorf("accountHolder is required")
+	}
+	account, err := c.registry.ValidateBankAccount(req.BIC, req.AccountNumber)
+	if err != nil {
+		return nil, err
+	}
+	bank, _ := c.registry.Bank(req.BIC)
+	if req.CountryCode != "" && !strings.EqualFold(bank.Country, req.CountryCode) {
+		return nil, fmt.Errorf("bank %s is not in country %s", bank.BIC, req.CountryCode)
+	}
+	// Keep the branch part of the BIC, dropping only the head-office XXX
+	req.BIC = strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(req.BIC)), "XXX")
+	req.AccountNumber = account
+	return c.PayWalletToBank(ctx, req.WalletToMobileRequest())
+}
+
+// normalizeBankAccount removes spaces and dashes from an account number
+func normalizeBankAccount(accountNumber string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(accountNumber))
+}
+
+// joinLengths formats allowed lengths as "10 or 12"
+func joinLengths(lengths []int) string {
+	parts := make([]string, len(lengths))
+	for i, n := range lengths {
+		parts[i] = fmt.Sprint(n)
+	}
+	return strings.Join(parts, " or ")
+}
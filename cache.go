@@ -0,0 +1,190 @@
+package temboplus
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Cache keys for read-only endpoints
+const (
+	cacheKeyMainBalance       = "balance:main"
+	cacheKeyCollectionBalance = "balance:collection"
+	cacheKeyWalletList        = "wallets"
+	cacheKeyWalletPrefix      = "balance:wallet:"
+)
+
+// responseCache is a TTL cache for read-only endpoints. Concurrent misses
+// for the same key share a single request.
+type responseCache struct {
+	ttl     time.Duration
+	timeout time.Duration // limit for a shared fetch
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	calls   map[string]*cacheCall
+	gen     uint64 // bumped on every invalidation
+}
+
+type cacheEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// cacheCall is an in-flight request shared by concurrent callers
+type cacheCall struct {
+	done  chan struct{}
+	gen   uint64 // cache generation when the request started
+	value interface{}
+	err   error
+}
+
+func newResponseCache(ttl, timeout time.Duration) *responseCache {
+	return &responseCache{
+		ttl:     ttl,
+		timeout: timeout,
+		entries: make(map[string]cacheEntry),
+		calls:   make(map[string]*cacheCall),
+	}
+}
+
+// do returns the cached value for key or calls fetch once for all
+// concurrent callers, caching a successful result. The shared fetch runs
+// detached from the callers' cancellation, bounded by the cache timeout, so
+// one caller giving up does not fail the others.
+func (rc *responseCache) do(ctx context.Context, key string, fetch func(context.Context) (interface{}, error)) (interface{}, error) {
+	rc.mu.Lock()
+	if e, ok := rc.entries[key]; ok && time.Now().Before(e.expires) {
+		rc.mu.Unlock()
+		return e.value, nil
+	}
+	// A request started before an invalidation may return a stale value, so
+	// later callers do not join it
+	call, ok := rc.calls[key]
+	if !ok || call.gen != rc.gen {
+		call = &cacheCall{done: make(chan struct{}), gen: rc.gen}
+		rc.calls[key] = call
+		go rc.fetch(context.WithoutCancel(ctx), key, call, fetch)
+	}
+	rc.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetch runs a shared request and stores its result
+func (rc *responseCache) fetch(ctx context.Context, key string, call *cacheCall, fetch func(context.Context) (interface{}, error)) {
+	if rc.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rc.timeout)
+		defer cancel()
+	}
+	call.value, call.err = fetch(ctx)
+
+	rc.mu.Lock()
+	// Only store the result if nothing was invalidated meanwhile
+	if rc.calls[key] == call {
+		delete(rc.calls, key)
+		if call.err == nil && call.gen == rc.gen {
+			rc.entries[key] = cacheEntry{value: call.value, expires: time.Now().Add(rc.ttl)}
+		}
+	}
+	rc.mu.Unlock()
+	close(call.done)
+}
+
+// invalidate drops keys and forgets their in-flight requests
+func (rc *responseCache) invalidate(keys ...string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.gen++
+	for _, key := range keys {
+		delete(rc.entries, key)
+		delete(rc.calls, key)
+	}
+}
+
+// invalidateAccount drops every cached balance belonging to accountNo.
+// In-flight requests are not stored since their account is not yet known.
+func (rc *responseCache) invalidateAccount(accountNo string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.gen++
+	delete(rc.entries, cacheKeyWalletPrefix+accountNo)
+	delete(rc.calls, cacheKeyWalletPrefix+accountNo)
+	for _, key := range []string{cacheKeyMainBalance, cacheKeyCollectionBalance} {
+		if e, ok := rc.entries[key]; ok {
+			if b, ok := e.value.(*CollectionBalanceResponse); ok && b.AccountNo == accountNo {
+				delete(rc.entries, key)
+			}
+		}
+	}
+}
+
+// cachedAccountNo returns the account number of a cached balance, if any
+func (rc *responseCache) cachedAccountNo(key string) string {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if e, ok := rc.entries[key]; ok {
+		if b, ok := e.value.(*CollectionBalanceResponse); ok {
+			return b.AccountNo
+		}
+	}
+	return ""
+}
+
+func (rc *responseCache) clear() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.gen++
+	rc.entries = make(map[string]cacheEntry)
+	rc.calls = make(map[string]*cacheCall)
+}
+
+// cachedBalance serves a balance endpoint through the response cache
+func (c *Client) cachedBalance(ctx context.Context, key string, fetch func(context.Context) (*CollectionBalanceResponse, error)) (*CollectionBalanceResponse, error) {
+	if c.cache == nil {
+		return fetch(ctx)
+	}
+	v, err := c.cache.do(ctx, key, func(ctx context.Context) (interface{}, error) {
+		return fetch(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Hand out copies so callers cannot modify the cached value
+	b := *v.(*CollectionBalanceResponse)
+	return &b, nil
+}
+
+// InvalidateCache drops every cached response
+func (c *Client) InvalidateCache() {
+	if c.cache != nil {
+		c.cache.clear()
+	}
+}
+
+// InvalidateBalance drops cached balances of the given account and the
+// wallet list. It is called automatically after payouts and collections.
+func (c *Client) InvalidateBalance(accountNo string) {
+	if c.cache == nil {
+		return
+	}
+	c.cache.invalidateAccount(accountNo)
+	c.cache.invalidate(cacheKeyWalletList)
+}
+
+// invalidateAfterCollection drops the collection balance and any cached
+// wallet balance of the collection account
+func (c *Client) invalidateAfterCollection() {
+	if c.cache == nil {
+		return
+	}
+	if accountNo := c.cache.cachedAccountNo(cacheKeyCollectionBalance); accountNo != "" {
+		c.cache.invalidateAccount(accountNo)
+	}
+	c.cache.invalidate(cacheKeyCollectionBalance)
+}
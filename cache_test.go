@@ -0,0 +1,181 @@
+package temboplus_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	temboplus "github.com/techliana/temboplus-golang-sdk"
+	"github.com/techliana/temboplus-golang-sdk/temboplustest"
+)
+
+// requestCount counts requests the fake server received for a path
+func requestCount(srv *temboplustest.Server, path string) int {
+	n := 0
+	for _, r := range srv.Requests() {
+		if r.Path == path {
+			n++
+		}
+	}
+	return n
+}
+
+func TestCacheInvalidatedAfterPayout(t *testing.T) {
+	srv := temboplustest.NewServer()
+	defer srv.Close()
+	srv.AutoWebhook = false
+	srv.Credit(temboplustest.MainAccountNo, 10000, "Deposit")
+	client := temboplus.NewClient(temboplus.ClientConfig{BaseURL: srv.URL, CacheTTL: time.Minute})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		b, err := client.GetMainBalance(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.AvailableBalance != 10000 {
+			t.Fatalf("balance = %.2f, want 10000", b.AvailableBalance)
+		}
+	}
+	if n := requestCount(srv, temboplus.EndpointWalletMainBalance); n != 1 {
+		t.Fatalf("%d balance requests, want 1 while cached", n)
+	}
+
+	_, err := client.PayWalletToMobile(ctx, temboplus.WalletToMobileRequest{
+		CountryCode:     "TZ",
+		AccountNo:       temboplustest.MainAccountNo,
+		ServiceCode:     "TZ-TIGO-B2C",
+		Amount:          2500,
+		MSISDN:          "255715123456",
+		Narration:       "Payout",
+		CurrencyCode:    "TZS",
+		RecipientNames:  "Asha Juma",
+		TransactionRef:  "PAY-1",
+		TransactionDate: temboplus.FormatTransactionDate(time.Now()),
+		CallbackURL:     "https://example.com/webhooks/temboplus",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := client.GetMainBalance(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.AvailableBalance != 7500 {
+		t.Errorf("balance after payout = %.2f, want 7500", b.AvailableBalance)
+	}
+	if n := requestCount(srv, temboplus.EndpointWalletMainBalance); n != 2 {
+		t.Errorf("%d balance requests, want 2 after the payout invalidated the cache", n)
+	}
+}
+
+func TestCacheSharedFetchSurvivesCallerCancel(t *testing.T) {
+	srv := temboplustest.NewServer()
+	defer srv.Close()
+	srv.Script(temboplus.EndpointWalletMainBalance, temboplustest.Delay(100*time.Millisecond))
+	client := temboplus.NewClient(temboplus.ClientConfig{BaseURL: srv.URL, CacheTTL: time.Minute})
+
+	first, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	var firstErr, secondErr error
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, firstErr = client.GetMainBalance(first)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	go func() {
+		defer wg.Done()
+		_, secondErr = client.GetMainBalance(context.Background())
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	wg.Wait()
+
+	if !errors.Is(firstErr, context.Canceled) {
+		t.Errorf("cancelled caller: error = %v, want context.Canceled", firstErr)
+	}
+	if secondErr != nil {
+		t.Errorf("waiting caller: error = %v, want the shared result", secondErr)
+	}
+	if n := requestCount(srv, temboplus.EndpointWalletMainBalance); n != 1 {
+		t.Errorf("%d balance requests, want 1 shared request", n)
+	}
+}
+
+func TestCacheDropsFetchStartedBeforePayout(t *testing.T) {
+	srv := temboplustest.NewServer()
+	defer srv.Close()
+	srv.AutoWebhook = false
+	srv.Credit(temboplustest.MainAccountNo, 10000, "Deposit")
+
+	// The proxy holds back the first balance response until the payout is
+	// done, so it arrives carrying the balance from before the payout
+	target, _ := url.Parse(srv.URL)
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	fetched, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	proxy.ModifyResponse = func(resp *http.Response) error {
+		if resp.Request.URL.Path == temboplus.EndpointWalletMainBalance {
+			once.Do(func() {
+				close(fetched)
+				<-release
+			})
+		}
+		return nil
+	}
+	front := httptest.NewServer(proxy)
+	defer front.Close()
+	client := temboplus.NewClient(temboplus.ClientConfig{BaseURL: front.URL, CacheTTL: time.Minute})
+	ctx := context.Background()
+
+	stale := make(chan float64, 1)
+	go func() {
+		b, err := client.GetMainBalance(ctx)
+		if err != nil {
+			t.Error(err)
+			stale <- 0
+			return
+		}
+		stale <- b.AvailableBalance
+	}()
+	<-fetched
+	_, err := client.PayWalletToMobile(ctx, temboplus.WalletToMobileRequest{
+		CountryCode:     "TZ",
+		AccountNo:       temboplustest.MainAccountNo,
+		ServiceCode:     "TZ-TIGO-B2C",
+		Amount:          2500,
+		MSISDN:          "255715123456",
+		Narration:       "Payout",
+		CurrencyCode:    "TZS",
+		RecipientNames:  "Asha Juma",
+		TransactionRef:  "PAY-1",
+		TransactionDate: temboplus.FormatTransactionDate(time.Now()),
+		CallbackURL:     "https://example.com/webhooks/temboplus",
+	})
+	close(release)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := <-stale; got != 10000 {
+		t.Fatalf("in-flight balance = %.2f, want 10000", got)
+	}
+
+	b, err := client.GetMainBalance(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.AvailableBalance != 7500 {
+		t.Errorf("balance after payout = %.2f, want 7500", b.AvailableBalance)
+	}
+	if n := requestCount(srv, temboplus.EndpointWalletMainBalance); n != 2 {
+		t.Errorf("%d balance requests, want 2", n)
+	}
+}
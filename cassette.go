@@ -0,0 +1,253 @@
+package temboplus
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// CassetteMode controls recording and replaying of HTTP interactions
+type CassetteMode string
+
+const (
+	// CassetteOff sends requests to TemboPlus as usual
+	CassetteOff CassetteMode = ""
+	// CassetteRecord sends requests to TemboPlus and appends each
+	// interaction, scrubbed of credentials and MSISDNs, to the cassette file
+	CassetteRecord CassetteMode = "record"
+	// CassetteReplay answers requests from the cassette file without
+	// touching the network
+	CassetteReplay CassetteMode = "replay"
+)
+
+// Cassette is the on-disk format of recorded interactions
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a single recorded request/response pair
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the scrubbed request part of an Interaction
+type RecordedRequest struct {
+	Method  string          `json:"method"`
+	Path    string          `json:"path"`
+	Body    json.RawMessage `json:"body,omitempty"`
+	RawBody bool            `json:"rawBody,omitempty"` // Body is a JSON string holding a non-JSON body
+}
+
+// RecordedResponse is the scrubbed response part of an Interaction
+type RecordedResponse struct {
+	StatusCode  int             `json:"statusCode"`
+	ContentType string          `json:"contentType,omitempty"`
+	Body        json.RawMessage `json:"body,omitempty"`
+	RawBody     bool            `json:"rawBody,omitempty"` // Body is a JSON string holding a non-JSON body
+}
+
+// msisdnPattern matches Tanzanian MSISDNs, keeping the operator prefix
+var msisdnPattern = regexp.MustCompile(`\b(255[67]\d{2})\d{6}\b`)
+
+// ScrubMSISDNs masks the subscriber part of every MSISDN in b, keeping the
+// country code and operator prefix so recorded data stays realistic
+func ScrubMSISDNs(b []byte) []byte {
+	return msisdnPattern.ReplaceAll(b, []byte("${1}000000"))
+}
+
+// cassetteTransport records or replays interactions
+type cassetteTransport struct {
+	mode CassetteMode
+	path string
+	next http.RoundTripper
+	err  error // configuration error; every request fails with it
+
+	mu       sync.Mutex
+	loaded   bool
+	cassette Cassette
+	used     []bool
+}
+
+func newCassetteTransport(mode CassetteMode, path string, next http.RoundTripper) *cassetteTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	t := &cassetteTransport{mode: mode, path: path, next: next}
+	switch {
+	case mode != CassetteRecord && mode != CassetteReplay:
+		t.err = fmt.Errorf("cassette: unknown mode %q, want %q or %q", string(mode), CassetteRecord, CassetteReplay)
+	case strings.TrimSpace(path) == "":
+		t.err = fmt.Errorf("cassette: CassettePath is required in %s mode", mode)
+	}
+	return t
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *cassetteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.err != nil {
+		// Never fall back to the live API on a misconfigured cassette
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, t.err
+	}
+	var reqBody []byte
+	if req.Body != nil {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("cassette: failed to read request body: %w", err)
+		}
+		reqBody = b
+		req.Body = io.NopCloser(bytes.NewReader(b))
+	}
+
+	if t.mode == CassetteReplay {
+		return t.replay(req, reqBody)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("cassette: failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	in := Interaction{
+		Request:  RecordedRequest{Method: req.Method, Path: req.URL.Path},
+		Response: RecordedResponse{StatusCode: resp.StatusCode, ContentType: resp.Header.Get("Content-Type")},
+	}
+	in.Request.Body, in.Request.RawBody = encodeBody(ScrubMSISDNs(reqBody))
+	in.Response.Body, in.Response.RawBody = encodeBody(ScrubMSISDNs(respBody))
+	if err := t.record(in); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// replay returns the first unused interaction with the same method, path
+// and request: the same transactionRef when the body has one, else the
+// same body
+func (t *cassetteTransport) replay(req *http.Request, body []byte) (*http.Response, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.load(); err != nil {
+		return nil, err
+	}
+	key := requestKey(ScrubMSISDNs(body))
+	for i, in := range t.cassette.Interactions {
+		if t.used[i] || in.Request.Method != req.Method || in.Request.Path != req.URL.Path ||
+			requestKey(decodeBody(in.Request.Body, in.Request.RawBody)) != key {
+			continue
+		}
+		t.used[i] = true
+		respBody := decodeBody(in.Response.Body, in.Response.RawBody)
+		contentType := in.Response.ContentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+			StatusCode:    in.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": []string{contentType}},
+			Body:          io.NopCloser(bytes.NewReader(respBody)),
+			ContentLength: int64(len(respBody)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("cassette: no recorded interaction left for %s %s", req.Method, req.URL.Path)
+}
+
+// requestKey identifies a request body for replay: its transactionRef, or
+// else the compacted body, since other bodies carry no timestamps
+func requestKey(body []byte) string {
+	var fields struct {
+		TransactionRef string `json:"transactionRef"`
+	}
+	if json.Unmarshal(body, &fields) == nil && fields.TransactionRef != "" {
+		return "ref:" + fields.TransactionRef
+	}
+	var buf bytes.Buffer
+	if json.Compact(&buf, body) == nil {
+		return "body:" + buf.String()
+	}
+	return "body:" + string(body)
+}
+
+// record appends an interaction and rewrites the cassette file
+func (t *cassetteTransport) record(in Interaction) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cassette.Interactions = append(t.cassette.Interactions, in)
+
+	data, err := json.MarshalIndent(t.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cassette: failed to marshal: %w", err)
+	}
+	if dir := filepath.Dir(t.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("cassette: failed to create directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(t.path, data, 0o644); err != nil {
+		return fmt.Errorf("cassette: failed to write %s: %w", t.path, err)
+	}
+	return nil
+}
+
+// load reads the cassette file once; must be called with t.mu held
+func (t *cassetteTransport) load() error {
+	if t.loaded {
+		return nil
+	}
+	data, err := os.ReadFile(t.path)
+	if err != nil {
+		return fmt.Errorf("cassette: failed to read %s: %w", t.path, err)
+	}
+	if err := json.Unmarshal(data, &t.cassette); err != nil {
+		return fmt.Errorf("cassette: failed to parse %s: %w", t.path, err)
+	}
+	t.used = make([]bool, len(t.cassette.Interactions))
+	t.loaded = true
+	return nil
+}
+
+// encodeBody keeps a JSON body as-is and stores anything else, e.g. a
+// gateway's HTML error page, as a JSON string with the raw flag set
+func encodeBody(b []byte) (json.RawMessage, bool) {
+	if len(b) == 0 {
+		return nil, false
+	}
+	if json.Valid(b) {
+		return json.RawMessage(b), false
+	}
+	s, _ := json.Marshal(string(b))
+	return json.RawMessage(s), true
+}
+
+// decodeBody returns the bytes encodeBody stored
+func decodeBody(body json.RawMessage, raw bool) []byte {
+	if !raw {
+		return body
+	}
+	var s string
+	if err := json.Unmarshal(body, &s); err != nil {
+		return body
+	}
+	return []byte(s)
+}
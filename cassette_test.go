@@ -0,0 +1,131 @@
+package temboplus_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	temboplus "github.com/techliana/temboplus-golang-sdk"
+	"github.com/techliana/temboplus-golang-sdk/temboplustest"
+)
+
+func collect(client *temboplus.Client, ref string) (*temboplus.MobileMoneyCollectionResponse, error) {
+	return client.CollectFromMobileMoney(context.Background(), temboplus.MobileMoneyCollectionRequest{
+		MSISDN:          "255715123456",
+		Channel:         temboplus.ChannelAuto,
+		Amount:          5000,
+		Narration:       "Order " + ref,
+		TransactionRef:  ref,
+		TransactionDate: temboplus.FormatTransactionDate(time.Now()),
+		CallbackURL:     "https://example.com/webhooks/temboplus",
+	})
+}
+
+func TestCassetteRecordReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	srv := temboplustest.NewServer()
+	srv.AutoWebhook = false
+	srv.Credit(temboplustest.MainAccountNo, 7000, "Deposit")
+	recorder := temboplus.NewClient(temboplus.ClientConfig{BaseURL: srv.URL, CassetteMode: temboplus.CassetteRecord, CassettePath: path})
+
+	recorded := map[string]string{}
+	for _, ref := range []string{"ORDER-1", "ORDER-2"} {
+		resp, err := collect(recorder, ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		recorded[ref] = resp.TransactionID
+	}
+	if _, err := recorder.GetMainBalance(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("255715123456")) {
+		t.Error("cassette contains an unscrubbed MSISDN")
+	}
+	if !bytes.Contains(data, []byte("255715000000")) {
+		t.Error("cassette does not contain the scrubbed MSISDN")
+	}
+
+	// The server is gone, so every answer comes from the cassette; the
+	// collections are replayed in reverse order and matched by reference
+	replayer := temboplus.NewClient(temboplus.ClientConfig{BaseURL: srv.URL, CassetteMode: temboplus.CassetteReplay, CassettePath: path})
+	for _, ref := range []string{"ORDER-2", "ORDER-1"} {
+		resp, err := collect(replayer, ref)
+		if err != nil {
+			t.Fatalf("%s: %v", ref, err)
+		}
+		if resp.TransactionRef != ref || resp.TransactionID != recorded[ref] {
+			t.Errorf("%s: replayed %s/%s, want %s/%s", ref, resp.TransactionRef, resp.TransactionID, ref, recorded[ref])
+		}
+	}
+	b, err := replayer.GetMainBalance(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.AvailableBalance != 7000 {
+		t.Errorf("replayed balance = %.2f, want 7000", b.AvailableBalance)
+	}
+	if _, err := collect(replayer, "ORDER-3"); err == nil || !strings.Contains(err.Error(), "no recorded interaction") {
+		t.Errorf("unrecorded request: error = %v, want no recorded interaction", err)
+	}
+}
+
+func TestCassetteReplaysNonJSONBody(t *testing.T) {
+	const page = "<html><body>502 Bad Gateway</body></html>"
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte(page))
+	}))
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	recorder := temboplus.NewClient(temboplus.ClientConfig{BaseURL: gateway.URL, CassetteMode: temboplus.CassetteRecord, CassettePath: path})
+	_, recordErr := recorder.GetMainBalance(context.Background())
+	gateway.Close()
+	if recordErr == nil {
+		t.Fatal("recording: expected an error from the gateway")
+	}
+
+	replayer := temboplus.NewClient(temboplus.ClientConfig{BaseURL: gateway.URL, CassetteMode: temboplus.CassetteReplay, CassettePath: path})
+	_, err := replayer.GetMainBalance(context.Background())
+	if err == nil || err.Error() != recordErr.Error() {
+		t.Errorf("replayed error = %v, want %v", err, recordErr)
+	}
+	if err == nil || !strings.Contains(err.Error(), page) {
+		t.Errorf("replayed error = %v, want the original page", err)
+	}
+}
+
+func TestCassetteMisconfigured(t *testing.T) {
+	srv := temboplustest.NewServer()
+	defer srv.Close()
+	tests := []struct {
+		name string
+		mode temboplus.CassetteMode
+		path string
+	}{
+		{"unknown mode", "replay-or-record", filepath.Join(t.TempDir(), "cassette.json")},
+		{"missing path", temboplus.CassetteRecord, ""},
+	}
+	for _, tt := range tests {
+		client := temboplus.NewClient(temboplus.ClientConfig{BaseURL: srv.URL, CassetteMode: tt.mode, CassettePath: tt.path})
+		if _, err := client.GetMainBalance(context.Background()); err == nil || !strings.Contains(err.Error(), "cassette") {
+			t.Errorf("%s: error = %v, want a cassette error", tt.name, err)
+		}
+	}
+	if n := len(srv.Requests()); n != 0 {
+		t.Errorf("%d requests reached the server, want none", n)
+	}
+}
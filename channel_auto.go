@@ -0,0 +1,52 @@
+package temboplus
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ChannelAuto asks CollectFromMobileMoney to pick the collection channel
+// from the MSISDN prefix
+const ChannelAuto = "auto"
+
+// ResolveChannel parses an MSISDN and returns it with its collection
+// channel. Numbers in ClientConfig.ChannelOverrides use their configured
+// channel; all others use the channel of the operator owning their prefix.
+func (c *Client) ResolveChannel(msisdn string) (*PhoneNumber, string, error) {
+	p, err := c.registry.ParsePhoneNumber("TZ", msisdn)
+	if err != nil {
+		// Overrides may name numbers outside the known prefixes
+		if channel, ok := c.channelOverrides[FormatMSISDN(msisdn)]; ok {
+			return &PhoneNumber{Raw: msisdn, MSISDN: FormatMSISDN(msisdn)}, channel, nil
+		}
+		return nil, "", fmt.Errorf("cannot select channel automatically: %w", err)
+	}
+	if channel, ok := c.channelOverrides[p.MSISDN]; ok {
+		return p, channel, nil
+	}
+	channel, err := p.C2BChannel()
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot select channel automatically: %w", err)
+	}
+	return p, channel, nil
+}
+
+// overrideKey normalizes an MSISDN for the override table
+func (c *Client) overrideKey(msisdn string) string {
+	if p, err := c.registry.ParsePhoneNumber("TZ", msisdn); err == nil {
+		return p.MSISDN
+	}
+	return FormatMSISDN(msisdn)
+}
+
+// newChannelOverrides normalizes the configured override table
+func (c *Client) newChannelOverrides(overrides map[string]string) map[string]string {
+	if len(overrides) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(overrides))
+	for msisdn, channel := range overrides {
+		out[c.overrideKey(msisdn)] = strings.ToUpper(strings.TrimSpace(channel))
+	}
+	return out
+}
@@ -0,0 +1,508 @@
+package temboplus
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Checkout defaults
+const (
+	defaultCheckoutExpiry          = 30 * time.Minute
+	defaultCheckoutPollInterval    = 5 * time.Second
+	defaultCheckoutMaxAttempts     = 3
+	defaultCheckoutAttemptInterval = 30 * time.Second
+	defaultCheckoutPendingGrace    = 10 * time.Minute
+)
+
+// CheckoutStatus is the state of a checkout session
+type CheckoutStatus string
+
+const (
+	CheckoutOpen    CheckoutStatus = "OPEN"    // Waiting for the customer's phone number
+	CheckoutPending CheckoutStatus = "PENDING" // Collection sent, waiting for the customer to confirm
+	CheckoutPaid    CheckoutStatus = "PAID"    // Collection accepted
+	CheckoutFailed  CheckoutStatus = "FAILED"  // Last attempt failed; the customer may try again
+	CheckoutExpired CheckoutStatus = "EXPIRED" // Expired, out of attempts or never confirmed before payment
+)
+
+// IsFinal reports whether the session can no longer change
+func (s CheckoutStatus) IsFinal() bool {
+	return s == CheckoutPaid || s == CheckoutExpired
+}
+
+// CheckoutSession is a payment link for a fixed amount
+type CheckoutSession struct {
+	ID                string            `json:"id"`
+	Amount            float64           `json:"amount"`
+	Currency          string            `json:"currency"`
+	Narration         string            `json:"narration"`
+	Metadata          map[string]string `json:"metadata,omitempty"`
+	Status            CheckoutStatus    `json:"status"`
+	MSISDN            string            `json:"msisdn,omitempty"`
+	Channel           string            `json:"channel,omitempty"`
+	TransactionRef    string            `json:"transactionRef,omitempty"` // Reference of the latest attempt
+	TransactionID     string            `json:"transactionId,omitempty"`
+	TransactionStatus TransactionStatus `json:"transactionStatus,omitempty"`
+	Attempts          int               `json:"attempts"`
+	AttemptedAt       time.Time         `json:"attemptedAt,omitzero"` // Start of the latest attempt
+	Error             string            `json:"error,omitempty"`      // Why the last attempt failed
+	CreatedAt         time.Time         `json:"createdAt"`
+	ExpiresAt         time.Time         `json:"expiresAt"`
+	UpdatedAt         time.Time         `json:"updatedAt"`
+	CheckedAt         time.Time         `json:"checkedAt,omitzero"` // Last status poll
+}
+
+// CheckoutStore keeps checkout sessions. Implementations must be safe for
+// concurrent use and must find a session by any TransactionRef it has had.
+type CheckoutStore interface {
+	SaveSession(ctx context.Context, s CheckoutSession) error
+	// GetSession returns ErrNotFound for unknown IDs
+	GetSession(ctx context.Context, id string) (CheckoutSession, error)
+	// SessionByTransactionRef returns ErrNotFound for unknown references
+	SessionByTransactionRef(ctx context.Context, transactionRef string) (CheckoutSession, error)
+}
+
+// MemoryCheckoutStore is an in-memory CheckoutStore
+type MemoryCheckoutStore struct {
+	mu       sync.Mutex
+	sessions map[string]CheckoutSession
+	byRef    map[string]string
+}
+
+// NewMemoryCheckoutStore returns an empty MemoryCheckoutStore
+func NewMemoryCheckoutStore() *MemoryCheckoutStore {
+	return &MemoryCheckoutStore{
+		sessions: make(map[string]CheckoutSession),
+		byRef:    make(map[string]string),
+	}
+}
+
+// SaveSession implements CheckoutStore
+func (m *MemoryCheckoutStore) SaveSession(ctx context.Context, s CheckoutSession) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s.Metadata = maps.Clone(s.Metadata)
+	m.sessions[s.ID] = s
+	if s.TransactionRef != "" {
+		m.byRef[s.TransactionRef] = s.ID
+	}
+	return nil
+}
+
+// GetSession implements CheckoutStore
+func (m *MemoryCheckoutStore) GetSession(ctx context.Context, id string) (CheckoutSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	if !ok {
+		return CheckoutSession{}, ErrNotFound
+	}
+	s.Metadata = maps.Clone(s.Metadata)
+	return s, nil
+}
+
+// SessionByTransactionRef implements CheckoutStore
+func (m *MemoryCheckoutStore) SessionByTransactionRef(ctx context.Context, transactionRef string) (CheckoutSession, error) {
+	m.mu.Lock()
+	id, ok := m.byRef[transactionRef]
+	m.mu.Unlock()
+	if !ok {
+		return CheckoutSession{}, ErrNotFound
+	}
+	return m.GetSession(ctx, id)
+}
+
+// CheckoutConfig configures a Checkout
+type CheckoutConfig struct {
+	Client       API           // Client used to collect and poll
+	CallbackURL  string        // Webhook URL sent with collections; route it to Checkout.HandleWebhook
+	Store        CheckoutStore // Default: NewMemoryCheckoutStore()
+	Channel      string        // Collection channel; default: ChannelAuto
+	Title        string        // Page title; default: "Checkout"
+	Expiry       time.Duration // Default session lifetime; default: 30 minutes
+	PollInterval time.Duration // Minimum time between status polls of a pending session; default: 5 seconds
+	PendingGrace time.Duration // How long a pending session may outlive its expiry before it is given up; default: 10 minutes
+
+	// Limits on payment prompts, since anyone with the link can start one
+	MaxAttempts     int           // Collections per session; default: 3
+	AttemptInterval time.Duration // Minimum time between collections of a session; default: 30 seconds
+}
+
+// Checkout creates payment sessions and serves their checkout pages. A
+// pending session is resolved by polling GetCollectionStatus, either when
+// its webhook arrives (HandleWebhook) or while its page is open.
+type Checkout struct {
+	client          API
+	callbackURL     string
+	store           CheckoutStore
+	channel         string
+	title           string
+	expiry          time.Duration
+	pollInterval    time.Duration
+	pendingGrace    time.Duration
+	maxAttempts     int
+	attemptInterval time.Duration
+
+	// mu serializes read-modify-write cycles on sessions
+	mu sync.Mutex
+}
+
+// NewCheckout returns a Checkout
+func NewCheckout(cfg CheckoutConfig) *Checkout {
+	c := &Checkout{
+		client:          cfg.Client,
+		callbackURL:     cfg.CallbackURL,
+		store:           cfg.Store,
+		channel:         cfg.Channel,
+		title:           cfg.Title,
+		expiry:          cfg.Expiry,
+		pollInterval:    cfg.PollInterval,
+		pendingGrace:    cfg.PendingGrace,
+		maxAttempts:     cfg.MaxAttempts,
+		attemptInterval: cfg.AttemptInterval,
+	}
+	if c.store == nil {
+		c.store = NewMemoryCheckoutStore()
+	}
+	if c.channel == "" {
+		c.channel = ChannelAuto
+	}
+	if c.title == "" {
+		c.title = "Checkout"
+	}
+	if c.expiry <= 0 {
+		c.expiry = defaultCheckoutExpiry
+	}
+	if c.pollInterval <= 0 {
+		c.pollInterval = defaultCheckoutPollInterval
+	}
+	if c.pendingGrace <= 0 {
+		c.pendingGrace = defaultCheckoutPendingGrace
+	}
+	if c.maxAttempts <= 0 {
+		c.maxAttempts = defaultCheckoutMaxAttempts
+	}
+	if c.attemptInterval <= 0 {
+		c.attemptInterval = defaultCheckoutAttemptInterval
+	}
+	return c
+}
+
+// CheckoutSessionRequest describes a new checkout session
+type CheckoutSessionRequest struct {
+	Amount    float64           // Amount to collect
+	Currency  string            // Default: TZS
+	Narration string            // Shown to the customer and sent with the collection
+	ExpiresIn time.Duration     // Default: CheckoutConfig.Expiry
+	Metadata  map[string]string // Your own data, e.g. an order ID
+}
+
+// CreateSession starts a checkout session. Send the customer to the
+// session's page, i.e. the Handler mount path followed by the session ID.
+func (c *Checkout) CreateSession(ctx context.Context, req CheckoutSessionRequest) (*CheckoutSession, error) {
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("amount must be greater than 0")
+	}
+	if req.Narration == "" {
+		return nil, fmt.Errorf("narration is required")
+	}
+	if req.Currency == "" {
+		req.Currency = "TZS"
+	}
+	if req.ExpiresIn <= 0 {
+		req.ExpiresIn = c.expiry
+	}
+	id, err := newCheckoutID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	s := CheckoutSession{
+		ID:        id,
+		Amount:    req.Amount,
+		Currency:  strings.ToUpper(req.Currency),
+		Narration: req.Narration,
+		Metadata:  maps.Clone(req.Metadata),
+		Status:    CheckoutOpen,
+		CreatedAt: now,
+		ExpiresAt: now.Add(req.ExpiresIn),
+		UpdatedAt: now,
+	}
+	if err := c.store.SaveSession(ctx, s); err != nil {
+		return nil, fmt.Errorf("failed to save checkout session: %w", err)
+	}
+	return &s, nil
+}
+
+// Session returns a session, marking it expired when its time is up
+func (c *Checkout) Session(ctx context.Context, id string) (*CheckoutSession, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, err := c.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// CheckoutInputError is a problem with what the customer entered
+type CheckoutInputError struct {
+	Message string
+}
+
+func (e *CheckoutInputError) Error() string {
+	return e.Message
+}
+
+// Pay sends a collection for the session to msisdn. The session must be
+// open, or failed and not expired, and the previous attempt at least
+// AttemptInterval ago. A *CheckoutInputError reports an unusable phone
+// number or an attempt made too soon. The session only fails when the
+// collection is refused; after a timeout or server error it stays pending,
+// since the customer may already have been prompted, until Refresh or
+// HandleWebhook learns its status.
+func (c *Checkout) Pay(ctx context.Context, id, msisdn string) (*CheckoutSession, error) {
+	phone, err := ParseMSISDN(msisdn)
+	if err != nil {
+		return nil, &CheckoutInputError{Message: "Enter a valid mobile money number, e.g. 0712 345 678"}
+	}
+
+	c.mu.Lock()
+	s, err := c.load(ctx, id)
+	if err != nil {
+		c.mu.Unlock()
+		return nil, err
+	}
+	if s.Status != CheckoutOpen && s.Status != CheckoutFailed {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("checkout session %s is %s", id, strings.ToLower(string(s.Status)))
+	}
+	if wait := c.attemptInterval - time.Since(s.AttemptedAt); wait > 0 {
+		c.mu.Unlock()
+		return nil, &CheckoutInputError{Message: fmt.Sprintf("Please wait %d seconds before trying again.", int(wait.Seconds())+1)}
+	}
+	// The reference must not be derivable from the public session ID
+	ref, err := randomHex(10)
+	if err != nil {
+		c.mu.Unlock()
+		return nil, err
+	}
+	s.Attempts++
+	s.AttemptedAt = time.Now()
+	s.Status = CheckoutPending
+	s.MSISDN = phone.MSISDN
+	s.TransactionRef = "CHK" + strings.ToUpper(ref)
+	s.TransactionID = ""
+	s.TransactionStatus = ""
+	s.Channel = ""
+	s.Error = ""
+	s.UpdatedAt = time.Now()
+	err = c.store.SaveSession(ctx, s)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to save checkout session: %w", err)
+	}
+
+	resp, err := c.client.CollectFromMobileMoney(ctx, MobileMoneyCollectionRequest{
+		MSISDN:          phone.MSISDN,
+		Channel:         c.channel,
+		Amount:          s.Amount,
+		Narration:       s.Narration,
+		TransactionRef:  s.TransactionRef,
+		TransactionDate: FormatTransactionDate(time.Now()),
+		CallbackURL:     c.callbackURL,
+	})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Reload in case the webhook arrived first
+	s, lerr := c.store.GetSession(ctx, id)
+	if lerr != nil {
+		return nil, fmt.Errorf("failed to reload checkout session: %w", lerr)
+	}
+	switch {
+	case resp != nil:
+		s.TransactionID = resp.TransactionID
+		s.Channel = resp.Channel
+		c.apply(&s, s.TransactionRef, resp.StatusCode)
+	case err != nil && collectionRefused(err):
+		s.Status = CheckoutFailed
+		s.Error = "We could not start the payment. Check the number or use another mobile money network."
+		s.UpdatedAt = time.Now()
+	}
+	if err := c.store.SaveSession(ctx, s); err != nil {
+		return nil, fmt.Errorf("failed to save checkout session: %w", err)
+	}
+	return &s, nil
+}
+
+// Refresh polls the status of a pending session, at most once per
+// PollInterval, and returns the session. A session still pending
+// PendingGrace after it expired gets a last poll and then expires.
+func (c *Checkout) Refresh(ctx context.Context, id string) (*CheckoutSession, error) {
+	c.mu.Lock()
+	s, err := c.load(ctx, id)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if s.Status != CheckoutPending || (!c.givenUp(s) && time.Now().Sub(s.CheckedAt) < c.pollInterval) {
+		return &s, nil
+	}
+
+	// A failed poll is not fatal; the next poll or the webhook resolves the session
+	resp, _ := c.client.GetCollectionStatus(ctx, PaymentStatusRequest{TransactionRef: s.TransactionRef, TransactionID: s.TransactionID})
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, err = c.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.CheckedAt = time.Now()
+	if resp != nil {
+		c.apply(&s, resp.TransactionRef, resp.StatusCode)
+	}
+	if s.Status == CheckoutPending && c.givenUp(s) {
+		s.Status = CheckoutExpired
+		s.Error = "We could not confirm the payment. If you were charged, contact the merchant."
+		s.UpdatedAt = time.Now()
+	}
+	if err := c.store.SaveSession(ctx, s); err != nil {
+		return nil, fmt.Errorf("failed to save checkout session: %w", err)
+	}
+	return &s, nil
+}
+
+// HandleWebhook resolves the session a collection webhook belongs to.
+// Webhooks are not authenticated, so the status they carry is ignored: the
+// transaction's status is confirmed with GetCollectionStatus instead.
+// Webhooks for other transactions are ignored. It has the signature of
+// WebhookHandlerFunc, so it can be passed to Client.WebhookHandler.
+func (c *Checkout) HandleWebhook(ctx context.Context, webhook *WebhookPayload) error {
+	c.mu.Lock()
+	s, err := c.store.SessionByTransactionRef(ctx, webhook.TransactionRef)
+	c.mu.Unlock()
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load checkout session: %w", err)
+	}
+	if s.Status == CheckoutPaid {
+		return nil
+	}
+
+	req := PaymentStatusRequest{TransactionRef: webhook.TransactionRef}
+	if webhook.TransactionRef == s.TransactionRef {
+		req.TransactionID = s.TransactionID
+	}
+	resp, err := c.client.GetCollectionStatus(ctx, req)
+	if resp == nil {
+		// Failing lets TemboPlus retry the webhook
+		return fmt.Errorf("failed to confirm checkout payment %s: %w", webhook.TransactionRef, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, err = c.load(ctx, s.ID)
+	if err != nil {
+		return err
+	}
+	if !c.apply(&s, webhook.TransactionRef, resp.StatusCode) {
+		return nil
+	}
+	return c.store.SaveSession(ctx, s)
+}
+
+// load reads a session and expires it if its time is up or its attempts
+// are used up; must be called with c.mu held
+func (c *Checkout) load(ctx context.Context, id string) (CheckoutSession, error) {
+	s, err := c.store.GetSession(ctx, id)
+	if err != nil {
+		return CheckoutSession{}, err
+	}
+	expired := (s.Status == CheckoutOpen || s.Status == CheckoutFailed) && time.Now().After(s.ExpiresAt)
+	exhausted := s.Status == CheckoutFailed && s.Attempts >= c.maxAttempts
+	if expired || exhausted {
+		s.Status = CheckoutExpired
+		s.Error = ""
+		if exhausted && !expired {
+			s.Error = "Too many payment attempts. Ask the merchant for a new payment link."
+		}
+		s.UpdatedAt = time.Now()
+		if err := c.store.SaveSession(ctx, s); err != nil {
+			return CheckoutSession{}, fmt.Errorf("failed to save checkout session: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// givenUp reports whether a pending session is past its expiry and grace
+// period
+func (c *Checkout) givenUp(s CheckoutSession) bool {
+	return time.Now().After(s.ExpiresAt.Add(c.pendingGrace))
+}
+
+// collectionRefused reports whether a collection error means no prompt was
+// sent: the client rejected the request before sending it or TemboPlus
+// refused it. Transport errors, timeouts and server errors leave the
+// outcome unknown.
+func collectionRefused(err error) bool {
+	var apiErr APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 400 && apiErr.StatusCode < 500 && apiErr.StatusCode != http.StatusRequestTimeout
+	}
+	var reqErr *RequestError
+	return !errors.As(err, &reqErr) && !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled)
+}
+
+// apply moves a session according to the status of one of its attempts and
+// reports whether it changed. Success from any attempt pays the session,
+// since the money was received; other statuses only count for the latest
+// attempt.
+func (c *Checkout) apply(s *CheckoutSession, transactionRef string, status TransactionStatus) bool {
+	if s.Status == CheckoutPaid {
+		return false
+	}
+	if status.IsSuccess() {
+		s.Status = CheckoutPaid
+		s.TransactionRef = transactionRef
+		s.TransactionStatus = status
+		s.Error = ""
+		s.UpdatedAt = time.Now()
+		return true
+	}
+	if transactionRef != s.TransactionRef || !CanTransition(s.TransactionStatus, status) || s.TransactionStatus == status {
+		return false
+	}
+	s.TransactionStatus = status
+	if status.IsFailure() {
+		s.Status = CheckoutFailed
+		s.Error = "The payment was not completed. Please try again."
+	}
+	s.UpdatedAt = time.Now()
+	return true
+}
+
+// newCheckoutID returns a random session ID
+func newCheckoutID() (string, error) {
+	return randomHex(12)
+}
+
+// randomHex returns n random bytes, hex encoded
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate random ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
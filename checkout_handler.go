@@ -0,0 +1,222 @@
+package temboplus
+
+import (
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Handler serves checkout pages. Mount it under a prefix with
+// http.StripPrefix, e.g.
+//
+//	mux.Handle("/pay/", http.StripPrefix("/pay/", checkout.Handler()))
+//
+// and send customers to /pay/<session ID>. Routes, relative to the prefix:
+//
+//	GET  <id>         checkout page
+//	POST <id>/pay     start the collection (form field "msisdn")
+//	GET  <id>/status  session as JSON, polling TemboPlus while pending
+func (c *Checkout) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, action, _ := strings.Cut(strings.Trim(r.URL.Path, "/"), "/")
+		if id == "" {
+			http.NotFound(w, r)
+			return
+		}
+		if action == "" && strings.HasSuffix(r.URL.Path, "/") {
+			// Relative links on the page need the URL without a trailing slash
+			w.Header().Set("Location", "../"+id)
+			w.WriteHeader(http.StatusMovedPermanently)
+			return
+		}
+		switch action {
+		case "":
+			if !allowMethod(w, r, http.MethodGet) {
+				return
+			}
+			s, err := c.Refresh(r.Context(), id)
+			if err != nil {
+				c.sessionError(w, r, err)
+				return
+			}
+			c.render(w, http.StatusOK, s, "")
+		case "pay":
+			if !allowMethod(w, r, http.MethodPost) {
+				return
+			}
+			c.servePay(w, r, id)
+		case "status":
+			if !allowMethod(w, r, http.MethodGet) {
+				return
+			}
+			s, err := c.Refresh(r.Context(), id)
+			if err != nil {
+				c.sessionError(w, r, err)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Cache-Control", "no-store")
+			json.NewEncoder(w).Encode(checkoutStatusView{Status: s.Status, Error: s.Error})
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// checkoutStatusView is the public part of a session returned by the status route
+type checkoutStatusView struct {
+	Status CheckoutStatus `json:"status"`
+	Error  string         `json:"error,omitempty"`
+}
+
+func (c *Checkout) servePay(w http.ResponseWriter, r *http.Request, id string) {
+	r.Body = http.MaxBytesReader(w, r.Body, 4096)
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid form", http.StatusBadRequest)
+		return
+	}
+	_, err := c.Pay(r.Context(), id, r.PostForm.Get("msisdn"))
+	var input *CheckoutInputError
+	if errors.As(err, &input) {
+		s, serr := c.Session(r.Context(), id)
+		if serr != nil {
+			c.sessionError(w, r, serr)
+			return
+		}
+		c.render(w, http.StatusBadRequest, s, input.Message)
+		return
+	}
+	if errors.Is(err, ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	// Other errors are shown by the session page itself. The redirect is
+	// relative to <id>/pay so it works under any mount prefix.
+	w.Header().Set("Location", "../"+id)
+	w.WriteHeader(http.StatusSeeOther)
+}
+
+func (c *Checkout) sessionError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	http.Error(w, "checkout unavailable", http.StatusInternalServerError)
+}
+
+// checkoutPage is the data rendered by checkoutTemplate
+type checkoutPage struct {
+	Title      string
+	Session    *CheckoutSession
+	Amount     string
+	Expires    string
+	InputError string
+	PollMillis int64
+}
+
+func (c *Checkout) render(w http.ResponseWriter, status int, s *CheckoutSession, inputError string) {
+	page := checkoutPage{
+		Title:      c.title,
+		Session:    s,
+		Amount:     s.Currency + " " + groupThousands(s.Amount),
+		Expires:    s.ExpiresAt.In(tanzaniaLocation).Format("2 Jan 2006 15:04"),
+		InputError: inputError,
+		PollMillis: (3 * time.Second).Milliseconds(),
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	checkoutTemplate.Execute(w, page)
+}
+
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	return true
+}
+
+// groupThousands formats an amount as 10,000 or 10,000.50
+func groupThousands(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	whole, frac, _ := strings.Cut(s, ".")
+	sign := ""
+	if strings.HasPrefix(whole, "-") {
+		sign, whole = "-", whole[1:]
+	}
+	var b strings.Builder
+	for i, ch := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(ch)
+	}
+	if frac == "00" {
+		return sign + b.String()
+	}
+	return sign + b.String() + "." + frac
+}
+
+var checkoutTemplate = template.Must(template.New("checkout").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+{{if eq .Session.Status "PENDING"}}<noscript><meta http-equiv="refresh" content="5"></noscript>{{end}}
+<style>
+body{font-family:system-ui,sans-serif;background:#f4f5f7;margin:0;padding:2rem 1rem;color:#1f2933}
+main{max-width:26rem;margin:0 auto;background:#fff;border-radius:12px;padding:1.5rem;box-shadow:0 2px 8px rgba(0,0,0,.08)}
+h1{font-size:1.1rem;margin:0 0 1rem}
+.amount{font-size:2rem;font-weight:600;margin:.25rem 0}
+.muted{color:#616e7c;font-size:.9rem}
+label{display:block;margin:1.25rem 0 .5rem;font-weight:500}
+input{width:100%;box-sizing:border-box;padding:.75rem;font-size:1.1rem;border:1px solid #cbd2d9;border-radius:8px}
+button{width:100%;margin-top:1rem;padding:.85rem;font-size:1rem;border:0;border-radius:8px;background:#0b69a3;color:#fff;cursor:pointer}
+.error{color:#b42318;margin-top:.75rem}
+.status{margin-top:1.25rem;padding:1rem;border-radius:8px;background:#f0f4f8}
+.paid{background:#e3f9e5;color:#05400a}
+.expired{background:#fde8e8;color:#8a1c1c}
+</style>
+</head>
+<body>
+<main>
+<h1>{{.Title}}</h1>
+<p class="muted">{{.Session.Narration}}</p>
+<p class="amount">{{.Amount}}</p>
+{{- with .Session}}
+{{- if or (eq .Status "OPEN") (eq .Status "FAILED")}}
+<form method="post" action="{{.ID}}/pay">
+<label for="msisdn">Mobile money number</label>
+<input id="msisdn" name="msisdn" type="tel" inputmode="tel" autocomplete="tel" placeholder="0712 345 678" value="{{.MSISDN}}" required>
+{{- if $.InputError}}<p class="error">{{$.InputError}}</p>{{else if .Error}}<p class="error">{{.Error}}</p>{{end}}
+<button type="submit">Pay {{$.Amount}}</button>
+</form>
+<p class="muted">You will receive a prompt on your phone to confirm with your PIN. This link expires {{$.Expires}}.</p>
+{{- else if eq .Status "PENDING"}}
+<div class="status" id="status">Check your phone and enter your PIN to approve the payment.</div>
+<script>
+(function(){
+  var timer = setInterval(function(){
+    fetch({{.ID}} + "/status", {cache: "no-store"}).then(function(r){ return r.json(); }).then(function(s){
+      if (s.status !== "PENDING") { clearInterval(timer); location.reload(); }
+    }).catch(function(){});
+  }, {{$.PollMillis}});
+})();
+</script>
+{{- else if eq .Status "PAID"}}
+<div class="status paid">Payment received. Thank you!</div>
+{{- else}}
+<div class="status expired">{{with .Error}}{{.}}{{else}}This payment link has expired.{{end}}</div>
+{{- end}}
+{{- end}}
+</main>
+</body>
+</html>
+`))
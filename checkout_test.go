@@ -0,0 +1,193 @@
+package temboplus_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	temboplus "github.com/techliana/temboplus-golang-sdk"
+	"github.com/techliana/temboplus-golang-sdk/temboplustest"
+)
+
+func newTestCheckout(t *testing.T, srv *temboplustest.Server, cfg temboplus.CheckoutConfig) (*temboplus.Checkout, *temboplus.CheckoutSession) {
+	t.Helper()
+	if cfg.Client == nil {
+		cfg.Client = srv.Client()
+	}
+	cfg.CallbackURL = "https://example.com/webhooks/temboplus"
+	co := temboplus.NewCheckout(cfg)
+	s, err := co.CreateSession(context.Background(), temboplus.CheckoutSessionRequest{Amount: 12500, Narration: "Order 42"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return co, s
+}
+
+func TestCheckoutWebhookIsConfirmed(t *testing.T) {
+	srv := temboplustest.NewServer()
+	defer srv.Close()
+	srv.AutoWebhook = false
+	srv.WebhookDelay = 100 * time.Millisecond
+	co, s := newTestCheckout(t, srv, temboplus.CheckoutConfig{})
+	ctx := context.Background()
+
+	s, err := co.Pay(ctx, s.ID, "0715 123 456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Status != temboplus.CheckoutPending {
+		t.Fatalf("status = %s, want PENDING", s.Status)
+	}
+	if strings.Contains(s.TransactionRef, strings.ToUpper(s.ID)) {
+		t.Errorf("transaction ref %s is derived from the session ID", s.TransactionRef)
+	}
+
+	// A forged success webhook while TemboPlus still reports PENDING_ACK
+	forged := &temboplus.WebhookPayload{StatusCode: temboplus.StatusPaymentAccepted, TransactionRef: s.TransactionRef, TransactionID: "FORGED"}
+	if err := co.HandleWebhook(ctx, forged); err != nil {
+		t.Fatal(err)
+	}
+	if s, _ = co.Session(ctx, s.ID); s.Status != temboplus.CheckoutPending {
+		t.Fatalf("status after forged webhook = %s, want PENDING", s.Status)
+	}
+
+	// Once TemboPlus has accepted the collection the webhook pays the session
+	time.Sleep(150 * time.Millisecond)
+	if err := co.HandleWebhook(ctx, forged); err != nil {
+		t.Fatal(err)
+	}
+	if s, _ = co.Session(ctx, s.ID); s.Status != temboplus.CheckoutPaid {
+		t.Fatalf("status after accepted collection = %s, want PAID", s.Status)
+	}
+}
+
+func TestCheckoutAttemptLimits(t *testing.T) {
+	srv := temboplustest.NewServer()
+	defer srv.Close()
+	srv.AutoWebhook = false
+	srv.SetDefault(temboplus.EndpointCollection, temboplustest.Reject)
+	co, s := newTestCheckout(t, srv, temboplus.CheckoutConfig{MaxAttempts: 2, AttemptInterval: 50 * time.Millisecond})
+
+	app := httptest.NewServer(http.StripPrefix("/pay/", co.Handler()))
+	defer app.Close()
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	pay := func() int {
+		t.Helper()
+		resp, err := noRedirect.PostForm(app.URL+"/pay/"+s.ID+"/pay", url.Values{"msisdn": {"0715123456"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	steps := []struct {
+		name       string
+		wait       time.Duration
+		wantCode   int
+		wantStatus temboplus.CheckoutStatus
+		wantTries  int
+	}{
+		{"first attempt", 0, http.StatusSeeOther, temboplus.CheckoutFailed, 1},
+		{"retry within the interval", 0, http.StatusBadRequest, temboplus.CheckoutFailed, 1},
+		{"retry after the interval", 60 * time.Millisecond, http.StatusSeeOther, temboplus.CheckoutExpired, 2},
+		{"attempts used up", 60 * time.Millisecond, http.StatusSeeOther, temboplus.CheckoutExpired, 2},
+	}
+	for _, st := range steps {
+		time.Sleep(st.wait)
+		if code := pay(); code != st.wantCode {
+			t.Errorf("%s: HTTP %d, want %d", st.name, code, st.wantCode)
+		}
+		got, err := co.Session(context.Background(), s.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Status != st.wantStatus || got.Attempts != st.wantTries {
+			t.Errorf("%s: status %s after %d attempts, want %s after %d", st.name, got.Status, got.Attempts, st.wantStatus, st.wantTries)
+		}
+	}
+
+	var input *temboplus.CheckoutInputError
+	if _, err := co.Pay(context.Background(), s.ID, "nonsense"); !errors.As(err, &input) {
+		t.Errorf("invalid number: error = %v, want CheckoutInputError", err)
+	}
+}
+
+func TestCheckoutPayFailsOnlyWhenRefused(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario temboplustest.Scenario
+		want     temboplus.CheckoutStatus
+	}{
+		{"rejected", temboplustest.Reject, temboplus.CheckoutFailed},
+		{"refused by the API", temboplustest.Unauthorized, temboplus.CheckoutFailed},
+		{"server error", temboplustest.ServerError, temboplus.CheckoutPending},
+		{"timeout", temboplustest.Delay(200 * time.Millisecond), temboplus.CheckoutPending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := temboplustest.NewServer()
+			defer srv.Close()
+			srv.AutoWebhook = false
+			srv.Script(temboplus.EndpointCollection, tt.scenario)
+			client := temboplus.NewClient(temboplus.ClientConfig{BaseURL: srv.URL, Timeout: 50 * time.Millisecond})
+			co, s := newTestCheckout(t, srv, temboplus.CheckoutConfig{Client: client})
+
+			s, err := co.Pay(context.Background(), s.ID, "0715123456")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.Status != tt.want {
+				t.Errorf("status = %s, want %s", s.Status, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckoutPendingSessionExpires(t *testing.T) {
+	tests := []struct {
+		name       string
+		lastCheck  temboplustest.Scenario
+		wantStatus temboplus.CheckoutStatus
+	}{
+		{"status checks keep failing", temboplustest.ServerError, temboplus.CheckoutExpired},
+		{"last check finds the payment", temboplustest.Accept, temboplus.CheckoutPaid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := temboplustest.NewServer()
+			defer srv.Close()
+			srv.AutoWebhook = false
+			srv.Script(temboplus.EndpointCollectionStatus, temboplustest.ServerError)
+			srv.SetDefault(temboplus.EndpointCollectionStatus, tt.lastCheck)
+			co, s := newTestCheckout(t, srv, temboplus.CheckoutConfig{Expiry: 50 * time.Millisecond, PendingGrace: 50 * time.Millisecond})
+			ctx := context.Background()
+
+			if _, err := co.Pay(ctx, s.ID, "0715123456"); err != nil {
+				t.Fatal(err)
+			}
+			if s, _ = co.Refresh(ctx, s.ID); s.Status != temboplus.CheckoutPending {
+				t.Fatalf("status before expiry = %s, want PENDING", s.Status)
+			}
+
+			// Past the grace period the next refresh checks once more,
+			// although PollInterval has not passed
+			time.Sleep(120 * time.Millisecond)
+			s, err := co.Refresh(ctx, s.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", s.Status, tt.wantStatus)
+			}
+			if n := requestCount(srv, temboplus.EndpointCollectionStatus); n != 2 {
+				t.Errorf("%d status checks, want 2", n)
+			}
+		})
+	}
+}
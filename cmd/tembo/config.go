@@ -0,0 +1,126 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/joho/godotenv"
+	temboplus "github.com/techliana/temboplus-golang-sdk"
+)
+
+// Environment variables (and config file keys) holding credentials
+const (
+	envAccountID = "TEMBO_ACCOUNT_ID"
+	envSecretKey = "TEMBO_SECRET_KEY"
+	envEnv       = "TEMBO_ENV"
+	envBaseURL   = "TEMBO_BASE_URL"
+)
+
+// options holds the flags shared by every command
+type options struct {
+	configPath string
+	env        string
+	output     string
+}
+
+// newFlagSet creates a FlagSet for a command with the common flags registered
+func newFlagSet(name string) (*flag.FlagSet, *options) {
+	fs := flag.NewFlagSet("tembo "+name, flag.ContinueOnError)
+	opts := &options{}
+	fs.StringVar(&opts.configPath, "config", "", "credentials file in KEY=VALUE format")
+	fs.StringVar(&opts.env, "env", "", "environment: sandbox or production")
+	fs.StringVar(&opts.output, "output", "table", "output format: table or json")
+	return fs, opts
+}
+
+// defaultConfigPath returns $XDG_CONFIG_HOME/tembo/config.env
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "tembo", "config.env")
+}
+
+// client builds a temboplus client from the environment and config file.
+// Environment variables take precedence over the config file.
+func (o *options) client() (*temboplus.Client, error) {
+	if o.output != "table" && o.output != "json" {
+		return nil, usagef("invalid -output %q: must be table or json", o.output)
+	}
+
+	fileValues := map[string]string{}
+	path := o.configPath
+	if path == "" {
+		path = defaultConfigPath()
+	}
+	if path != "" {
+		values, err := godotenv.Read(path)
+		switch {
+		case err == nil:
+			fileValues = values
+		case o.configPath != "" || !os.IsNotExist(err):
+			return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+		}
+	}
+	lookup := func(key string) string {
+		if v := os.Getenv(key); v != "" {
+			return v
+		}
+		return fileValues[key]
+	}
+
+	env := o.env
+	if env == "" {
+		env = lookup(envEnv)
+	}
+	var environment temboplus.Environment
+	switch strings.ToLower(env) {
+	case "", string(temboplus.Sandbox):
+		environment = temboplus.Sandbox
+	case string(temboplus.Production):
+		environment = temboplus.Production
+	default:
+		return nil, usagef("invalid environment %q: must be sandbox or production", env)
+	}
+
+	accountID, secretKey := lookup(envAccountID), lookup(envSecretKey)
+	if accountID == "" || secretKey == "" {
+		return nil, fmt.Errorf("credentials not configured: set %s and %s or add them to %s", envAccountID, envSecretKey, path)
+	}
+
+	return temboplus.NewClient(temboplus.ClientConfig{
+		Environmen: environment,
+		AccountID:  accountID,
+		SecretKey:  secretKey,
+		BaseURL:    lookup(envBaseURL),
+	}), nil
+}
+
+// parse parses flags and returns the positional arguments, allowing flags to
+// appear before, between or after them
+func parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// arg returns the i'th positional argument or an empty string
+func arg(pos []string, i int) string {
+	if i < len(pos) {
+		return pos[i]
+	}
+	return ""
+}
@@ -0,0 +1,100 @@
+// Command tembo is an operator tool for the TemboPlus API. It checks
+// balances, statements and transaction status, and submits collections and
+// payouts, using the temboplus SDK.
+//
+// Usage:
+//
+//	tembo <command> [subcommand] [flags]
+//
+// Run "tembo help" for the list of commands.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+)
+
+const usage = `Usage: tembo <command> [subcommand] [flags]
+
+Commands:
+  balance main|collection         Show the main or collection account balance
+  balance wallet <accountNo>      Show the balance of a wallet
+  statement main|collection       Show an account statement (-from, -to, -wallet)
+  wallets list                    List wallets with their balances
+  status collection|payment       Look up a transaction (-ref and/or -id)
+  collect                         Send a USSD push collection request
+  payout                          Pay from a wallet to a mobile subscriber or bank
+  bulk-payout <file.csv>          Submit payouts listed in a CSV file
+  webhook listen                  Receive and print webhooks on a local port
+  webhook replay <file.json>...   Send saved webhook payloads to a handler
+
+Common flags:
+  -config <file>   Credentials file (default: $XDG_CONFIG_HOME/tembo/config.env)
+  -env <name>      sandbox or production (overrides TEMBO_ENV)
+  -output <fmt>    table or json (default: table)
+
+Credentials are read from TEMBO_ACCOUNT_ID, TEMBO_SECRET_KEY and TEMBO_ENV,
+falling back to the same keys in the config file.
+
+Run "tembo <command> -h" for command flags.
+`
+
+// command runs a subcommand with the remaining arguments
+type command func(ctx context.Context, args []string) error
+
+var commands = map[string]command{
+	"balance":     runBalance,
+	"statement":   runStatement,
+	"wallets":     runWallets,
+	"status":      runStatus,
+	"collect":     runCollect,
+	"payout":      runPayout,
+	"bulk-payout": runBulkPayout,
+	"webhook":     runWebhook,
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprint(os.Stderr, usage)
+		return 2
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "tembo: unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := cmd(ctx, args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 2
+		}
+		fmt.Fprintf(os.Stderr, "tembo %s: %v\n", args[0], err)
+		var uerr usageError
+		if errors.As(err, &uerr) {
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
+
+// usageError reports invalid command-line usage
+type usageError string
+
+func (e usageError) Error() string { return string(e) }
+
+// usagef returns a usageError with a formatted message
+func usagef(format string, args ...interface{}) error {
+	return usageError(fmt.Sprintf(format, args...))
+}
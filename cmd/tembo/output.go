@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// table is tabular output for the table format
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+// add appends a row, formatting each value with %v
+func (t *table) add(values ...interface{}) {
+	row := make([]string, len(values))
+	for i, v := range values {
+		row[i] = fmt.Sprint(v)
+	}
+	t.rows = append(t.rows, row)
+}
+
+// render writes v as JSON or t as an aligned table, depending on -output
+func (o *options) render(v interface{}, t *table) error {
+	return render(os.Stdout, o.output, v, t)
+}
+
+func render(w io.Writer, format string, v interface{}, t *table) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if len(t.header) > 0 {
+		fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+	}
+	for _, row := range t.rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+// money formats an amount with two decimals
+func money(v float64) string {
+	return fmt.Sprintf("%.2f", v)
+}
+
+// optionalMoney formats a nullable amount, leaving it blank when unset
+func optionalMoney(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return money(*v)
+}
@@ -0,0 +1,363 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	temboplus "github.com/techliana/temboplus-golang-sdk"
+)
+
+func runCollect(ctx context.Context, args []string) error {
+	fs, opts := newFlagSet("collect")
+	msisdn := fs.String("msisdn", "", "subscriber phone number")
+	channel := fs.String("channel", "", "MNO channel, e.g. "+temboplus.ChannelTZTigoC2B+", or "+temboplus.ChannelAuto+" to infer it from the MSISDN")
+	amount := fs.Float64("amount", 0, "amount to collect")
+	narration := fs.String("narration", "", "description shown to the subscriber")
+	callback := fs.String("callback", "", "webhook callback URL")
+	ref := fs.String("ref", "", "transaction reference (default: generated)")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	client, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	req := temboplus.BuildCollectionRequest(*msisdn, *channel, *amount, *narration, *callback)
+	if *ref != "" {
+		req.TransactionRef = *ref
+	}
+	resp, err := client.CollectFromMobileMoney(ctx, req)
+	if resp == nil {
+		return err
+	}
+	if rerr := printTransaction(opts, resp); rerr != nil {
+		return rerr
+	}
+	return err
+}
+
+// payoutFlags holds the flags shared by payout and bulk-payout
+type payoutFlags struct {
+	account  *string
+	callback *string
+	country  *string
+	currency *string
+}
+
+// registerPayoutFlags registers the shared payout flags on fs
+func registerPayoutFlags(fs *flag.FlagSet) payoutFlags {
+	return payoutFlags{
+		account:  fs.String("account", "", "source wallet account number"),
+		callback: fs.String("callback", "", "webhook callback URL"),
+		country:  fs.String("country", "TZ", "country code"),
+		currency: fs.String("currency", "TZS", "currency code"),
+	}
+}
+
+// request builds a WalletToMobileRequest from the shared flags
+func (p payoutFlags) request() temboplus.WalletToMobileRequest {
+	return temboplus.WalletToMobileRequest{
+		CountryCode:     *p.country,
+		AccountNo:       *p.account,
+		CurrencyCode:    *p.currency,
+		TransactionDate: temboplus.FormatTransactionDate(time.Now()),
+		CallbackURL:     *p.callback,
+	}
+}
+
+func runPayout(ctx context.Context, args []string) error {
+	fs, opts := newFlagSet("payout")
+	pf := registerPayoutFlags(fs)
+	service := fs.String("service", "", "service code, e.g. "+temboplus.ServiceTZTigoB2C)
+	msisdn := fs.String("msisdn", "", "recipient phone number, or <BIC>:<ACCOUNT> for bank payouts")
+	bic := fs.String("bic", "", "destination bank BIC for bank payouts, e.g. CORUTZTZ")
+	bankAccount := fs.String("bank-account", "", "destination bank account number (with -bic)")
+	amount := fs.Float64("amount", 0, "amount to pay")
+	name := fs.String("name", "", "recipient first and last names")
+	narration := fs.String("narration", "", "transfer narration")
+	ref := fs.String("ref", "", "transaction reference (default: generated)")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	client, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	req := pf.request()
+	req.ServiceCode = *service
+	req.MSISDN = *msisdn
+	req.Amount = *amount
+	req.RecipientNames = *name
+	req.Narration = *narration
+	req.TransactionRef = *ref
+
+	var resp *temboplus.MobileMoneyCollectionResponse
+	if *bic != "" {
+		resp, err = submitBankPayout(ctx, client, req, *bic, *bankAccount)
+	} else {
+		resp, err = submitPayout(ctx, client, req)
+	}
+	if resp == nil {
+		return err
+	}
+	if rerr := printTransaction(opts, resp); rerr != nil {
+		return rerr
+	}
+	return err
+}
+
+// submitPayout sends a payout, routing bank service codes to PayWalletToBank
+func submitPayout(ctx context.Context, client *temboplus.Client, req temboplus.WalletToMobileRequest) (*temboplus.MobileMoneyCollectionResponse, error) {
+	if req.TransactionRef == "" {
+		req.TransactionRef = temboplus.GenerateTransactionRef("PAYOUT")
+	}
+	if req.ServiceCode == temboplus.ServiceTZBankB2C {
+		return client.PayWalletToBank(ctx, req)
+	}
+	if !strings.Contains(req.MSISDN, ":") {
+		req.MSISDN = temboplus.FormatMSISDN(req.MSISDN)
+	}
+	return client.PayWalletToMobile(ctx, req)
+}
+
+// submitBankPayout sends a typed bank payout built from the shared fields
+func submitBankPayout(ctx context.Context, client *temboplus.Client, req temboplus.WalletToMobileRequest, bic, account string) (*temboplus.MobileMoneyCollectionResponse, error) {
+	if req.TransactionRef == "" {
+		req.TransactionRef = temboplus.GenerateTransactionRef("PAYOUT")
+	}
+	return client.PayToBankAccount(ctx, temboplus.BankPayoutRequest{
+		CountryCode:     req.CountryCode,
+		AccountNo:       req.AccountNo,
+		ServiceCode:     req.ServiceCode,
+		BIC:             bic,
+		AccountNumber:   account,
+		AccountHolder:   req.RecipientNames,
+		Amount:          req.Amount,
+		Narration:       req.Narration,
+		CurrencyCode:    req.CurrencyCode,
+		TransactionRef:  req.TransactionRef,
+		TransactionDate: req.TransactionDate,
+		CallbackURL:     req.CallbackURL,
+	})
+}
+
+// bulkResult is the outcome of one CSV row
+type bulkResult struct {
+	Line           int     `json:"line"`
+	MSISDN         string  `json:"msisdn"`
+	Amount         float64 `json:"amount"`
+	TransactionRef string  `json:"transactionRef"`
+	TransactionID  string  `json:"transactionId,omitempty"`
+	StatusCode     string  `json:"statusCode,omitempty"`
+	Error          string  `json:"error,omitempty"`
+}
+
+const bulkUsage = `CSV columns (header row required, order free):
+  msisdn, amount, service_code, recipient_names, narration[, transaction_ref]
+
+Rows without a transaction_ref get one derived from the file contents and
+line number, so re-running an unchanged file reuses the same references and
+TemboPlus can refuse the payouts already made. Add transaction_ref before
+editing a file that has been run.`
+
+func runBulkPayout(ctx context.Context, args []string) error {
+	fs, opts := newFlagSet("bulk-payout")
+	pf := registerPayoutFlags(fs)
+	dryRun := fs.Bool("dry-run", false, "validate the file without submitting payouts")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: tembo bulk-payout [flags] <file.csv>\n\n%s\n\nFlags:\n", bulkUsage)
+		fs.PrintDefaults()
+	}
+	pos, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) != 1 {
+		return usagef("expected exactly one CSV file")
+	}
+	data, err := os.ReadFile(pos[0])
+	if err != nil {
+		return err
+	}
+
+	rows, err := readPayoutCSV(data, pf.request())
+	if err != nil {
+		return err
+	}
+
+	// Every row is checked before anything is paid
+	results := make([]bulkResult, 0, len(rows))
+	invalid := 0
+	for _, row := range rows {
+		res := bulkResult{Line: row.line, MSISDN: row.req.MSISDN, Amount: row.req.Amount, TransactionRef: row.req.TransactionRef}
+		if row.err != nil {
+			res.Error = row.err.Error()
+			invalid++
+		} else if *dryRun {
+			res.StatusCode = "VALID"
+		}
+		results = append(results, res)
+	}
+	if *dryRun || invalid > 0 {
+		if err := renderBulkResults(opts, results); err != nil {
+			return err
+		}
+		if invalid > 0 {
+			return fmt.Errorf("%d of %d rows are invalid; nothing was submitted", invalid, len(rows))
+		}
+		return nil
+	}
+
+	client, err := opts.client()
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for i, row := range rows {
+		resp, err := submitPayout(ctx, client, row.req)
+		if resp != nil {
+			results[i].TransactionID = resp.TransactionID
+			results[i].StatusCode = string(resp.StatusCode)
+		}
+		if err != nil {
+			results[i].Error = err.Error()
+			failed++
+		}
+		if ctx.Err() != nil {
+			results = results[:i+1]
+			break
+		}
+	}
+	if err := renderBulkResults(opts, results); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d payouts failed", failed, len(rows))
+	}
+	return ctx.Err()
+}
+
+// renderBulkResults prints the outcome of each row
+func renderBulkResults(opts *options, results []bulkResult) error {
+	t := &table{header: []string{"LINE", "MSISDN", "AMOUNT", "REF", "ID", "STATUS", "ERROR"}}
+	for _, r := range results {
+		t.add(r.Line, r.MSISDN, money(r.Amount), r.TransactionRef, r.TransactionID, r.StatusCode, r.Error)
+	}
+	return opts.render(results, t)
+}
+
+// payoutRow is one CSV row; err is why it cannot be paid
+type payoutRow struct {
+	line int
+	req  temboplus.WalletToMobileRequest
+	err  error
+}
+
+// readPayoutCSV parses and validates payout rows, filling unset fields from
+// base. Rows without a transaction_ref get one derived from the file hash
+// and line number.
+func readPayoutCSV(data []byte, base temboplus.WalletToMobileRequest) ([]payoutRow, error) {
+	sum := sha256.Sum256(data)
+	fileID := strings.ToUpper(hex.EncodeToString(sum[:6]))
+
+	cr := csv.NewReader(bytes.NewReader(data))
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	col := make(map[string]int, len(header))
+	for i, h := range header {
+		col[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, required := range []string{"msisdn", "amount", "service_code", "recipient_names", "narration"} {
+		if _, ok := col[required]; !ok {
+			return nil, usagef("CSV is missing column %q\n%s", required, bulkUsage)
+		}
+	}
+	field := func(rec []string, name string) string {
+		if i, ok := col[name]; ok && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+
+	var rows []payoutRow
+	seen := make(map[string]int)
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		line, _ := cr.FieldPos(0)
+		row := payoutRow{line: line, req: base}
+		row.req.MSISDN = field(rec, "msisdn")
+		row.req.ServiceCode = field(rec, "service_code")
+		row.req.RecipientNames = field(rec, "recipient_names")
+		row.req.Narration = field(rec, "narration")
+		row.req.TransactionRef = field(rec, "transaction_ref")
+		if row.req.TransactionRef == "" {
+			row.req.TransactionRef = fmt.Sprintf("BULK_%s_%d", fileID, line)
+		}
+		amount, err := strconv.ParseFloat(field(rec, "amount"), 64)
+		if err != nil {
+			row.err = fmt.Errorf("invalid amount %q", field(rec, "amount"))
+		} else {
+			row.req.Amount = amount
+			row.err = validatePayoutRow(row.req)
+		}
+		if first, ok := seen[row.req.TransactionRef]; ok && row.err == nil {
+			row.err = fmt.Errorf("transaction_ref %s already used on line %d", row.req.TransactionRef, first)
+		}
+		seen[row.req.TransactionRef] = line
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// validatePayoutRow checks what the file controls: amount, names,
+// narration, service code, destination and the channel's limits
+func validatePayoutRow(req temboplus.WalletToMobileRequest) error {
+	registry := temboplus.DefaultRegistry()
+	if req.Amount <= 0 {
+		return fmt.Errorf("amount must be greater than 0")
+	}
+	if req.RecipientNames == "" {
+		return fmt.Errorf("recipient_names is required")
+	}
+	if req.Narration == "" {
+		return fmt.Errorf("narration is required")
+	}
+	service, ok := registry.Channel(req.ServiceCode)
+	if !ok || service.Direction != temboplus.DirectionB2C {
+		return fmt.Errorf("unknown payout service_code %q", req.ServiceCode)
+	}
+	if service.Bank {
+		bic, account, ok := strings.Cut(req.MSISDN, ":")
+		if !ok {
+			return fmt.Errorf("bank payouts need <BIC>:<ACCOUNT> in msisdn")
+		}
+		if _, err := registry.ValidateBankAccount(bic, account); err != nil {
+			return err
+		}
+	} else if _, err := registry.ParsePhoneNumber(req.CountryCode, req.MSISDN); err != nil {
+		return err
+	}
+	return registry.ValidateChannelAmount(service.Code, req.Amount)
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	temboplus "github.com/techliana/temboplus-golang-sdk"
+)
+
+func runBalance(ctx context.Context, args []string) error {
+	fs, opts := newFlagSet("balance")
+	pos, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) == 0 {
+		return usagef("expected main, collection or wallet <accountNo>")
+	}
+	client, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	var balance *temboplus.CollectionBalanceResponse
+	switch arg(pos, 0) {
+	case "main":
+		balance, err = client.GetMainBalance(ctx)
+	case "collection":
+		balance, err = client.GetCollectionBalance(ctx)
+	case "wallet":
+		if len(pos) < 2 {
+			return usagef("expected wallet <accountNo>")
+		}
+		balance, err = client.GetWalletBalance(ctx, arg(pos, 1))
+	default:
+		return usagef("unknown balance type %q", arg(pos, 0))
+	}
+	if err != nil {
+		return err
+	}
+
+	t := &table{header: []string{"ACCOUNT", "NAME", "STATUS", "AVAILABLE", "CURRENT"}}
+	t.add(balance.AccountNo, balance.AccountName, balance.AccountStatus, money(balance.AvailableBalance), money(balance.CurrentBalance))
+	return opts.render(balance, t)
+}
+
+func runStatement(ctx context.Context, args []string) error {
+	fs, opts := newFlagSet("statement")
+	today := time.Now().Format("2006-01-02")
+	from := fs.String("from", today, "start date (YYYY-MM-DD)")
+	to := fs.String("to", today, "end date (YYYY-MM-DD)")
+	wallet := fs.String("wallet", "", "optional wallet ID")
+	pos, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) == 0 {
+		return usagef("expected main or collection")
+	}
+	client, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	req := temboplus.CollectionStatementRequest{StartDate: *from, EndDate: *to, WalletID: *wallet}
+	var entries []temboplus.CollectionStatementEntry
+	switch arg(pos, 0) {
+	case "main":
+		entries, err = client.GetMainStatement(ctx, req)
+	case "collection":
+		entries, err = client.GetCollectionStatement(ctx, req)
+	default:
+		return usagef("unknown statement type %q", arg(pos, 0))
+	}
+	if err != nil {
+		return err
+	}
+
+	t := &table{header: []string{"DATE", "REF", "NARRATION", "CREDIT", "DEBIT", "BALANCE"}}
+	for _, e := range entries {
+		t.add(e.TxnDate, e.TranRefNo, e.Narration, optionalMoney(e.AmountCredited.Value), optionalMoney(e.AmountDebited.Value), money(e.Balance))
+	}
+	return opts.render(entries, t)
+}
+
+func runWallets(ctx context.Context, args []string) error {
+	fs, opts := newFlagSet("wallets")
+	pos, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if arg(pos, 0) != "list" {
+		return usagef("expected list")
+	}
+	client, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	wallets, err := client.Wallets().List(ctx)
+	if err != nil {
+		return err
+	}
+	t := &table{header: []string{"ACCOUNT", "NAME", "STATUS", "AVAILABLE", "CURRENT", "ERROR"}}
+	for _, w := range wallets {
+		t.add(w.AccountNo, w.AccountName, w.AccountStatus, money(w.AvailableBalance), money(w.CurrentBalance), w.Error)
+	}
+	return opts.render(wallets, t)
+}
+
+func runStatus(ctx context.Context, args []string) error {
+	fs, opts := newFlagSet("status")
+	ref := fs.String("ref", "", "transaction reference")
+	id := fs.String("id", "", "TemboPlus transaction ID")
+	pos, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if *ref == "" && *id == "" {
+		return usagef("-ref or -id is required")
+	}
+	client, err := opts.client()
+	if err != nil {
+		return err
+	}
+
+	req := temboplus.PaymentStatusRequest{TransactionRef: *ref, TransactionID: *id}
+	var resp *temboplus.MobileMoneyCollectionResponse
+	switch arg(pos, 0) {
+	case "collection":
+		resp, err = client.GetCollectionStatus(ctx, req)
+	case "payment":
+		resp, err = client.GetPaymentStatus(ctx, req)
+	default:
+		return usagef("expected collection or payment")
+	}
+	if resp == nil && err != nil {
+		return err
+	}
+	// Rejected transactions come back with both a response and an error;
+	// the status is still what the operator asked for.
+	return printTransaction(opts, resp)
+}
+
+// printTransaction renders a collection or payment response
+func printTransaction(opts *options, resp *temboplus.MobileMoneyCollectionResponse) error {
+	t := &table{header: []string{"STATUS", "REF", "ID"}}
+	if resp.Channel != "" {
+		t.header = append(t.header, "CHANNEL")
+		t.add(resp.StatusCode, resp.TransactionRef, resp.TransactionID, resp.Channel)
+	} else {
+		t.add(resp.StatusCode, resp.TransactionRef, resp.TransactionID)
+	}
+	return opts.render(resp, t)
+}
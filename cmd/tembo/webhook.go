@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	temboplus "github.com/techliana/temboplus-golang-sdk"
+)
+
+const webhookUsage = `Usage:
+  tembo webhook listen [-addr :8080] [-path /webhooks/temboplus] [-forward URL] [-save DIR]
+  tembo webhook replay -to URL <payload.json>...`
+
+func runWebhook(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return usagef("expected listen or replay\n%s", webhookUsage)
+	}
+	switch args[0] {
+	case "listen":
+		return runWebhookListen(ctx, args[1:])
+	case "replay":
+		return runWebhookReplay(ctx, args[1:])
+	default:
+		return usagef("unknown webhook command %q\n%s", args[0], webhookUsage)
+	}
+}
+
+func runWebhookListen(ctx context.Context, args []string) error {
+	fs, opts := newFlagSet("webhook listen")
+	addr := fs.String("addr", ":8080", "address to listen on")
+	path := fs.String("path", "/webhooks/temboplus", "callback path")
+	forward := fs.String("forward", "", "forward each valid payload to this URL")
+	save := fs.String("save", "", "directory to save each valid payload to")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	if *save != "" {
+		if err := os.MkdirAll(*save, 0o755); err != nil {
+			return err
+		}
+	}
+
+	// Webhook validation needs no credentials
+	client := temboplus.NewClient(temboplus.ClientConfig{})
+	forwarder := &http.Client{Timeout: 10 * time.Second}
+
+	mux := http.NewServeMux()
+	mux.Handle(*path, logInvalid(client.WebhookHandler(func(ctx context.Context, webhook *temboplus.WebhookPayload) error {
+		printWebhook(opts, webhook)
+		// Save and forward the bytes TemboPlus sent so replays are exact
+		body, _ := ctx.Value(rawBodyKey{}).([]byte)
+		if *save != "" {
+			name := fmt.Sprintf("%s_%s.json", time.Now().Format("20060102T150405.000"), webhook.TransactionRef)
+			if err := os.WriteFile(filepath.Join(*save, filepath.Base(name)), body, 0o644); err != nil {
+				fmt.Fprintf(os.Stderr, "  save failed: %v\n", err)
+			}
+		}
+		if *forward != "" {
+			status, err := post(ctx, forwarder, *forward, body)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "  forward failed: %v\n", err)
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "  forwarded to %s: %d\n", *forward, status)
+			if status >= 300 {
+				return fmt.Errorf("forward target answered %d", status)
+			}
+		}
+		return nil
+	})))
+
+	srv := &http.Server{Addr: *addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Fprintf(os.Stderr, "Listening for webhooks on %s%s (Ctrl+C to stop)\n", *addr, *path)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func runWebhookReplay(ctx context.Context, args []string) error {
+	fs, opts := newFlagSet("webhook replay")
+	to := fs.String("to", "", "URL of the webhook handler to replay to")
+	files, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if *to == "" || len(files) == 0 {
+		return usagef("-to and at least one payload file are required\n%s", webhookUsage)
+	}
+
+	client := temboplus.NewClient(temboplus.ClientConfig{})
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	t := &table{header: []string{"FILE", "REF", "STATUS", "RESPONSE"}}
+	type result struct {
+		File           string                      `json:"file"`
+		TransactionRef string                      `json:"transactionRef"`
+		StatusCode     temboplus.TransactionStatus `json:"statusCode"`
+		Response       int                         `json:"response"`
+	}
+	var results []result
+	failed := 0
+	for _, file := range files {
+		body, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		webhook, err := client.ValidateWebhook(body)
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		status, err := post(ctx, httpClient, *to, body)
+		if err != nil {
+			return err
+		}
+		if status >= 300 {
+			failed++
+		}
+		results = append(results, result{file, webhook.TransactionRef, webhook.StatusCode, status})
+		t.add(file, webhook.TransactionRef, webhook.StatusCode, status)
+	}
+	if err := opts.render(results, t); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d replays were not accepted", failed, len(files))
+	}
+	return nil
+}
+
+// printWebhook prints a received payload with its interpretation
+func printWebhook(opts *options, webhook *temboplus.WebhookPayload) {
+	if opts.output == "json" {
+		render(os.Stdout, "json", webhook, nil)
+		return
+	}
+	outcome := "unknown status"
+	switch {
+	case temboplus.IsSuccessfulWebhook(webhook):
+		outcome = "payment succeeded"
+	case temboplus.IsFailedWebhook(webhook):
+		outcome = "payment failed"
+	case webhook.StatusCode == temboplus.StatusPendingACK:
+		outcome = "pending"
+	}
+	fmt.Printf("[%s] %s\n  ref:    %s\n  id:     %s\n  status: %s\n",
+		time.Now().Format(time.TimeOnly), outcome, webhook.TransactionRef, webhook.TransactionID, webhook.StatusCode)
+}
+
+// rawBodyKey is the request context key for the received webhook body
+type rawBodyKey struct{}
+
+// logInvalid reports requests the webhook handler rejected. The received
+// body is kept in the request context under rawBodyKey.
+func logInvalid(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r = r.WithContext(context.WithValue(r.Context(), rawBodyKey{}, body))
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		if rec.status == http.StatusBadRequest || rec.status == http.StatusMethodNotAllowed {
+			fmt.Fprintf(os.Stderr, "[%s] rejected %s %s (%d): %s\n",
+				time.Now().Format(time.TimeOnly), r.Method, r.URL.Path, rec.status, bytes.TrimSpace(body))
+		}
+	})
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// post sends a JSON body and returns the response status code
+func post(ctx context.Context, client *http.Client, url string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
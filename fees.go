@@ -0,0 +1,132 @@
+package temboplus
+
+import (
+	"fmt"
+	"math"
+)
+
+// FeeTier is a charge applied to amounts between MinAmount and MaxAmount
+// inclusive
+type FeeTier struct {
+	MinAmount float64 `json:"minAmount"`
+	MaxAmount float64 `json:"maxAmount,omitempty"` // 0 means no upper bound
+	Fixed     float64 `json:"fixed,omitempty"`     // Flat charge
+	Percent   float64 `json:"percent,omitempty"`   // Charge as a percentage of the amount, e.g. 1.5
+}
+
+// fee returns the tier's charge for amount
+func (t FeeTier) fee(amount float64) float64 {
+	return t.Fixed + amount*t.Percent/100
+}
+
+// FeeSchedule holds the TemboPlus and operator charges of a channel. The
+// SDK ships without rates; set your contracted ones with SetChannelFees or
+// the "fees" field of LoadRegistry.
+type FeeSchedule struct {
+	TemboPlus []FeeTier `json:"temboplus,omitempty"`
+	Operator  []FeeTier `json:"operator,omitempty"`
+}
+
+// IsZero reports whether no charges are configured
+func (s FeeSchedule) IsZero() bool {
+	return len(s.TemboPlus) == 0 && len(s.Operator) == 0
+}
+
+func (s FeeSchedule) clone() FeeSchedule {
+	return FeeSchedule{
+		TemboPlus: append([]FeeTier(nil), s.TemboPlus...),
+		Operator:  append([]FeeTier(nil), s.Operator...),
+	}
+}
+
+// FeeEstimate is the estimated cost of a transaction
+type FeeEstimate struct {
+	ServiceCode  string  `json:"serviceCode"`
+	Currency     string  `json:"currency"`
+	Amount       float64 `json:"amount"`
+	TemboPlusFee float64 `json:"temboplusFee"`
+	OperatorFee  float64 `json:"operatorFee"`
+	TotalFee     float64 `json:"totalFee"`
+	Total        float64 `json:"total"` // Amount plus TotalFee, e.g. what a customer pays when fees are passed on
+}
+
+// SetChannelFees sets the fee schedule of a registered channel or service code
+func (r *Registry) SetChannelFees(code string, fees FeeSchedule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.channels[code]
+	if !ok {
+		return fmt.Errorf("unknown channel: %s", code)
+	}
+	c.Fees = fees.clone()
+	r.channels[code] = c
+	return nil
+}
+
+// EstimateFee estimates the charges for sending amount through a channel or
+// service code. The amount must be valid for the channel's currency and
+// limits, and the channel must have a fee schedule.
+func (r *Registry) EstimateFee(code string, amount float64) (FeeEstimate, error) {
+	channel, ok := r.Channel(code)
+	if !ok {
+		return FeeEstimate{}, fmt.Errorf("unknown channel: %s", code)
+	}
+	if channel.Fees.IsZero() {
+		return FeeEstimate{}, fmt.Errorf("no fee schedule configured for %s", code)
+	}
+	country, _ := r.Country(channel.Country)
+	if err := r.ValidateAmount(country.Currency, amount); err != nil {
+		return FeeEstimate{}, err
+	}
+	if err := r.ValidateChannelAmount(code, amount); err != nil {
+		return FeeEstimate{}, err
+	}
+
+	minorUnits := 0
+	if currency, ok := r.Currency(country.Currency); ok {
+		minorUnits = currency.MinorUnits
+	}
+	round := func(v float64) float64 {
+		scale := math.Pow10(minorUnits)
+		return math.Round(v*scale) / scale
+	}
+
+	tembo, err := tierFee(channel.Fees.TemboPlus, amount)
+	if err != nil {
+		return FeeEstimate{}, fmt.Errorf("%s TemboPlus fee: %w", code, err)
+	}
+	operator, err := tierFee(channel.Fees.Operator, amount)
+	if err != nil {
+		return FeeEstimate{}, fmt.Errorf("%s operator fee: %w", code, err)
+	}
+	e := FeeEstimate{
+		ServiceCode:  code,
+		Currency:     country.Currency,
+		Amount:       amount,
+		TemboPlusFee: round(tembo),
+		OperatorFee:  round(operator),
+	}
+	e.TotalFee = round(e.TemboPlusFee + e.OperatorFee)
+	e.Total = round(amount + e.TotalFee)
+	return e, nil
+}
+
+// tierFee returns the charge of the tier containing amount. An empty list
+// means no charge.
+func tierFee(tiers []FeeTier, amount float64) (float64, error) {
+	if len(tiers) == 0 {
+		return 0, nil
+	}
+	for _, t := range tiers {
+		if amount >= t.MinAmount && (t.MaxAmount == 0 || amount <= t.MaxAmount) {
+			return t.fee(amount), nil
+		}
+	}
+	return 0, fmt.Errorf("no fee tier covers amount %.2f", amount)
+}
+
+// EstimateFee estimates the charges for a transaction using the client's
+// registry. See Registry.EstimateFee.
+func (c *Client) EstimateFee(code string, amount float64) (FeeEstimate, error) {
+	return c.registry.EstimateFee(code, amount)
+}
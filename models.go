@@ -8,7 +8,7 @@ import (
 // MobileMoneyCollectionRequest represents a mobile money collection request
 type MobileMoneyCollectionRequest struct {
 	MSISDN          string  `json:"msisdn"`          // Phone number in format 255XXX123456
-	Channel         string  `json:"channel"`         // MNO channel (TZ-TIGO-C2B, TZ-AIRTEL-C2B)
+	Channel         string  `json:"channel"`         // MNO channel (TZ-TIGO-C2B, TZ-AIRTEL-C2B) or ChannelAuto
 	Amount          float64 `json:"amount"`          // Amount to collect
 	Narration       string  `json:"narration"`       // Description/narration
 	TransactionRef  string  `json:"transactionRef"`  // Your system reference
@@ -18,16 +18,17 @@ type MobileMoneyCollectionRequest struct {
 
 // MobileMoneyCollectionResponse represents the API response
 type MobileMoneyCollectionResponse struct {
-	StatusCode     string `json:"statusCode"`     // PENDING_ACK, PAYMENT_REJECTED, GENERIC_ERROR
-	TransactionRef string `json:"transactionRef"` // Your system reference
-	TransactionID  string `json:"transactionId"`  // TemboPlus transaction ID
+	StatusCode     TransactionStatus `json:"statusCode"`        // PENDING_ACK, PAYMENT_REJECTED, GENERIC_ERROR
+	TransactionRef string            `json:"transactionRef"`    // Your system reference
+	TransactionID  string            `json:"transactionId"`     // TemboPlus transaction ID
+	Channel        string            `json:"channel,omitempty"` // Channel used for a collection, set by the client
 }
 
 // WebhookPayload represents the webhook callback payload
 type WebhookPayload struct {
-	StatusCode     string `json:"statusCode"`     // PAYMENT_ACCEPTED, PAYMENT_REJECTED, GENERIC_ERROR
-	TransactionRef string `json:"transactionRef"` // Your system reference
-	TransactionID  string `json:"transactionId"`  // TemboPlus transaction ID
+	StatusCode     TransactionStatus `json:"statusCode"`     // PAYMENT_ACCEPTED, PAYMENT_REJECTED, GENERIC_ERROR
+	TransactionRef string            `json:"transactionRef"` // Your system reference
+	TransactionID  string            `json:"transactionId"`  // TemboPlus transaction ID
 }
 
 // Error represents an API error
@@ -56,6 +57,21 @@ func (e APIError) Error() string {
 	return fmt.Sprintf("Api Error [%d]", e.StatusCode)
 }
 
+// RequestError is a failure after a request was sent, or may have been: a
+// transport error, a timeout or an unreadable response. TemboPlus may have
+// acted on the request, so check the transaction's status before retrying.
+type RequestError struct {
+	Err error
+}
+
+func (e *RequestError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
 // CollectionBalanceResponse represents the response for collection account balance
 type CollectionBalanceResponse struct {
 	AvailableBalance float64 `json:"availableBalance"`
@@ -105,6 +121,14 @@ func (n *NullableFloat64) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// MarshalJSON encodes the value as a JSON number, or null when unset
+func (n NullableFloat64) MarshalJSON() ([]byte, error) {
+	if n.Value == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(*n.Value)
+}
+
 // Helper used by NullableFloat64
 func parseFloatFromString(s string) (float64, error) {
 	// Use json to parse a quoted number safely
@@ -132,6 +156,19 @@ type CollectionStatementEntry struct {
 	Balance        float64         `json:"balance"`
 }
 
+// Amount returns the signed amount of the entry: credits are positive,
+// debits negative
+func (e CollectionStatementEntry) Amount() float64 {
+	var amount float64
+	if e.AmountCredited.Value != nil {
+		amount += *e.AmountCredited.Value
+	}
+	if e.AmountDebited.Value != nil {
+		amount -= *e.AmountDebited.Value
+	}
+	return amount
+}
+
 // WalletToMobileRequest represents a wallet-to-mobile disbursement request
 type WalletToMobileRequest struct {
 	CountryCode     string  `json:"countryCode"`     // e.g., TZ
@@ -0,0 +1,154 @@
+package temboplus
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PhoneNumber is a parsed mobile number
+type PhoneNumber struct {
+	Raw      string    // Input as given
+	Country  string    // Country code, e.g. TZ
+	MSISDN   string    // International format without "+", e.g. 255715123456
+	National string    // National significant number, e.g. 715123456
+	Operator *Operator // Detected operator; nil when the prefix is unknown
+}
+
+// String returns the MSISDN
+func (p *PhoneNumber) String() string {
+	return p.MSISDN
+}
+
+// International returns the number formatted for display, e.g. +255 715 123 456
+func (p *PhoneNumber) International() string {
+	n := p.National
+	if len(n) == 9 {
+		n = n[:3] + " " + n[3:6] + " " + n[6:]
+	}
+	return "+" + strings.TrimSuffix(p.MSISDN, p.National) + " " + n
+}
+
+// C2BChannel returns the collection channel recommended for the number
+func (p *PhoneNumber) C2BChannel() (string, error) {
+	if p.Operator == nil {
+		return "", fmt.Errorf("unknown operator for MSISDN %s", p.MSISDN)
+	}
+	if p.Operator.C2BChannel == "" {
+		return "", fmt.Errorf("collections are not supported for %s numbers", p.Operator.Name)
+	}
+	return p.Operator.C2BChannel, nil
+}
+
+// B2CService returns the payout service code recommended for the number
+func (p *PhoneNumber) B2CService() (string, error) {
+	if p.Operator == nil {
+		return "", fmt.Errorf("unknown operator for MSISDN %s", p.MSISDN)
+	}
+	if p.Operator.B2CService == "" {
+		return "", fmt.Errorf("payouts are not supported for %s numbers", p.Operator.Name)
+	}
+	return p.Operator.B2CService, nil
+}
+
+// ParseMSISDN parses a Tanzanian mobile number using the default registry.
+// See Registry.ParsePhoneNumber.
+func ParseMSISDN(input string) (*PhoneNumber, error) {
+	return DefaultRegistry().ParsePhoneNumber("TZ", input)
+}
+
+// ParsePhoneNumber normalizes and validates a mobile number. Spaces, dashes,
+// dots and parentheses are ignored; "+" and "00" mark an international
+// number, whose country is found by dial code; otherwise the number is
+// taken as belonging to defaultCountry, with or without the trunk 0. When
+// operators are registered for the country the number must match one of
+// their prefixes.
+func (r *Registry) ParsePhoneNumber(defaultCountry, input string) (*PhoneNumber, error) {
+	digits, international, err := normalizePhoneInput(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var country Country
+	var ok bool
+	if international {
+		country, ok = r.countryByDialCode(digits)
+		if !ok {
+			return nil, fmt.Errorf("unsupported country code in MSISDN %s", input)
+		}
+	} else {
+		country, ok = r.Country(defaultCountry)
+		if !ok {
+			return nil, fmt.Errorf("unsupported countryCode: %s", defaultCountry)
+		}
+		switch {
+		case strings.HasPrefix(digits, "0") && len(digits) == country.NumberLength+1:
+			digits = country.DialCode + digits[1:]
+		case len(digits) == country.NumberLength:
+			digits = country.DialCode + digits
+		}
+	}
+
+	if err := r.ValidateMSISDN(country.Code, digits); err != nil {
+		return nil, err
+	}
+	p := &PhoneNumber{
+		Raw:      input,
+		Country:  country.Code,
+		MSISDN:   digits,
+		National: digits[len(country.DialCode):],
+	}
+	if op, ok := r.OperatorFor(country.Code, p.National); ok {
+		p.Operator = op
+	} else if len(r.Operators(country.Code)) > 0 {
+		return nil, fmt.Errorf("MSISDN %s does not match any %s operator prefix", digits, country.Name)
+	}
+	return p, nil
+}
+
+// countryByDialCode finds the country whose dial code prefixes digits,
+// preferring the longest dial code
+func (r *Registry) countryByDialCode(digits string) (Country, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	codes := make([]string, 0, len(r.countries))
+	for code := range r.countries {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	var best Country
+	for _, code := range codes {
+		c := r.countries[code]
+		if c.DialCode != "" && strings.HasPrefix(digits, c.DialCode) && len(c.DialCode) > len(best.DialCode) {
+			best = c
+		}
+	}
+	return best, best.Code != ""
+}
+
+// normalizePhoneInput strips separators and international prefixes. It
+// reports whether the number was written in international form.
+func normalizePhoneInput(input string) (digits string, international bool, err error) {
+	s := strings.TrimSpace(input)
+	if s == "" {
+		return "", false, fmt.Errorf("MSISDN is required")
+	}
+	var b strings.Builder
+	for i, ch := range s {
+		switch {
+		case ch >= '0' && ch <= '9':
+			b.WriteRune(ch)
+		case ch == '+' && i == 0:
+			international = true
+		case ch == ' ' || ch == '-' || ch == '.' || ch == '(' || ch == ')':
+		default:
+			return "", false, fmt.Errorf("invalid character %q in MSISDN %s", ch, input)
+		}
+	}
+	digits = b.String()
+	if strings.HasPrefix(digits, "00") {
+		digits = digits[2:]
+		international = true
+	}
+	return digits, international, nil
+}
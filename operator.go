@@ -0,0 +1,152 @@
+package temboplus
+
+import (
+	"strings"
+	"time"
+)
+
+// Operator describes a mobile network operator: its numbering prefixes,
+// TemboPlus channels and the metadata checkout UIs need
+type Operator struct {
+	Name       string   `json:"name"`                 // e.g. Tigo
+	Country    string   `json:"country"`              // Country code
+	Prefixes   []string `json:"prefixes"`             // Leading digits of national numbers, e.g. 71
+	C2BChannel string   `json:"c2bChannel,omitempty"` // TemboPlus collection channel, if any
+	B2CService string   `json:"b2cService,omitempty"` // TemboPlus payout service code, if any
+
+	DisplayName         string  `json:"displayName,omitempty"`         // Wallet brand shown to users, e.g. Tigo Pesa
+	LogoKey             string  `json:"logoKey,omitempty"`             // Stable key for looking up a logo asset
+	MinAmount           float64 `json:"minAmount,omitempty"`           // Typical smallest amount, for display; not enforced
+	MaxAmount           float64 `json:"maxAmount,omitempty"`           // Typical largest amount, for display; not enforced
+	USSDPrompt          string  `json:"ussdPrompt,omitempty"`          // What the subscriber sees when a collection is pushed
+	ConfirmationSeconds int     `json:"confirmationSeconds,omitempty"` // Typical time for the subscriber to confirm
+}
+
+// Directions returns the directions TemboPlus supports for the operator
+func (o Operator) Directions() []Direction {
+	var d []Direction
+	if o.C2BChannel != "" {
+		d = append(d, DirectionC2B)
+	}
+	if o.B2CService != "" {
+		d = append(d, DirectionB2C)
+	}
+	return d
+}
+
+// Supports reports whether TemboPlus supports the operator in a direction
+func (o Operator) Supports(direction Direction) bool {
+	switch direction {
+	case DirectionC2B:
+		return o.C2BChannel != ""
+	case DirectionB2C:
+		return o.B2CService != ""
+	}
+	return false
+}
+
+// ConfirmationTime returns the typical confirmation time, or zero if unknown
+func (o Operator) ConfirmationTime() time.Duration {
+	return time.Duration(o.ConfirmationSeconds) * time.Second
+}
+
+// tanzaniaOperators is the Tanzanian mobile numbering plan with each
+// operator's wallet brand. Limits, prompts and confirmation times are left
+// unset: they vary by agreement and tariff, so register them with
+// RegisterOperator or LoadRegistry from your own sources.
+var tanzaniaOperators = []Operator{
+	{
+		Name: "Vodacom", Country: "TZ", Prefixes: []string{"74", "75", "76"},
+		DisplayName: "M-Pesa", LogoKey: "vodacom-mpesa",
+	},
+	{
+		Name: "Tigo", Country: "TZ", Prefixes: []string{"65", "67", "71", "77"},
+		C2BChannel: ChannelTZTigoC2B, B2CService: ServiceTZTigoB2C,
+		DisplayName: "Tigo Pesa", LogoKey: "tigo-pesa",
+	},
+	{
+		Name: "Airtel", Country: "TZ", Prefixes: []string{"68", "69", "78"},
+		C2BChannel: ChannelTZAirtelC2B, B2CService: ServiceTZAirtelB2C,
+		DisplayName: "Airtel Money", LogoKey: "airtel-money",
+	},
+	{
+		Name: "Halotel", Country: "TZ", Prefixes: []string{"61", "62"}, C2BChannel: ChannelTZHalotelC2B,
+		DisplayName: "HaloPesa", LogoKey: "halopesa",
+	},
+	{
+		Name: "TTCL", Country: "TZ", Prefixes: []string{"73"},
+		DisplayName: "T-Pesa", LogoKey: "ttcl-tpesa",
+	},
+}
+
+// RegisterOperator adds or replaces an operator, keyed by country and name
+func (r *Registry) RegisterOperator(op Operator) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	op.Country = strings.ToUpper(op.Country)
+	op.Prefixes = append([]string(nil), op.Prefixes...)
+	ops := r.operators[op.Country]
+	for i := range ops {
+		if strings.EqualFold(ops[i].Name, op.Name) {
+			ops[i] = op
+			return
+		}
+	}
+	r.operators[op.Country] = append(ops, op)
+}
+
+// Operators returns the operators registered for a country. It is the
+// operator catalog used by checkout UIs.
+func (r *Registry) Operators(country string) []Operator {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ops := r.operators[strings.ToUpper(country)]
+	out := make([]Operator, len(ops))
+	for i, op := range ops {
+		op.Prefixes = append([]string(nil), op.Prefixes...)
+		out[i] = op
+	}
+	return out
+}
+
+// Operator looks up an operator by country and name
+func (r *Registry) Operator(country, name string) (Operator, bool) {
+	for _, op := range r.Operators(country) {
+		if strings.EqualFold(op.Name, name) {
+			return op, true
+		}
+	}
+	return Operator{}, false
+}
+
+// OperatorForChannel returns the operator behind a channel or service code
+func (r *Registry) OperatorForChannel(code string) (Operator, bool) {
+	channel, ok := r.Channel(code)
+	if !ok {
+		return Operator{}, false
+	}
+	for _, op := range r.Operators(channel.Country) {
+		if op.C2BChannel == code || op.B2CService == code || strings.EqualFold(op.Name, channel.Operator) {
+			return op, true
+		}
+	}
+	return Operator{}, false
+}
+
+// OperatorFor returns the operator whose longest prefix matches a national number
+func (r *Registry) OperatorFor(country, national string) (*Operator, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var best *Operator
+	bestLen := 0
+	for _, op := range r.operators[strings.ToUpper(country)] {
+		for _, p := range op.Prefixes {
+			if len(p) > bestLen && strings.HasPrefix(national, p) {
+				o := op
+				o.Prefixes = append([]string(nil), op.Prefixes...)
+				best, bestLen = &o, len(p)
+			}
+		}
+	}
+	return best, best != nil
+}
@@ -0,0 +1,96 @@
+package temboplus
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defaultPreflightTTL is how long a wallet balance is reused by the payout preflight
+const defaultPreflightTTL = 15 * time.Second
+
+// InsufficientFundsError is returned by the payout preflight when the source
+// wallet's available balance is below the payout amount
+type InsufficientFundsError struct {
+	AccountNo string
+	Available float64
+	Required  float64
+}
+
+// Shortfall returns the amount missing from the wallet
+func (e *InsufficientFundsError) Shortfall() float64 {
+	return e.Required - e.Available
+}
+
+func (e *InsufficientFundsError) Error() string {
+	return fmt.Sprintf("insufficient funds in wallet %s: available %.2f, required %.2f, shortfall %.2f",
+		e.AccountNo, e.Available, e.Required, e.Shortfall())
+}
+
+// balanceCache keeps recently fetched wallet balances for the preflight
+type balanceCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]cachedBalance
+}
+
+type cachedBalance struct {
+	available float64
+	expires   time.Time
+}
+
+func newBalanceCache(ttl time.Duration) *balanceCache {
+	if ttl <= 0 {
+		ttl = defaultPreflightTTL
+	}
+	return &balanceCache{ttl: ttl, entries: make(map[string]cachedBalance)}
+}
+
+func (b *balanceCache) get(accountNo string) (float64, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	e, ok := b.entries[accountNo]
+	if !ok || time.Now().After(e.expires) {
+		return 0, false
+	}
+	return e.available, true
+}
+
+func (b *balanceCache) set(accountNo string, available float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries[accountNo] = cachedBalance{available: available, expires: time.Now().Add(b.ttl)}
+}
+
+// debit lowers a cached balance after a submitted payout so consecutive
+// payouts within the TTL are checked against what is left
+func (b *balanceCache) debit(accountNo string, amount float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if e, ok := b.entries[accountNo]; ok {
+		e.available -= amount
+		b.entries[accountNo] = e
+	}
+}
+
+// checkSufficientFunds returns an InsufficientFundsError when the wallet
+// cannot cover amount. It is a no-op unless BalancePreflight is enabled.
+func (c *Client) checkSufficientFunds(ctx context.Context, accountNo string, amount float64) error {
+	if c.preflight == nil {
+		return nil
+	}
+	available, ok := c.preflight.get(accountNo)
+	if !ok {
+		balance, err := c.GetWalletBalance(ctx, accountNo)
+		if err != nil {
+			return fmt.Errorf("balance preflight failed: %w", err)
+		}
+		available = balance.AvailableBalance
+		c.preflight.set(accountNo, available)
+	}
+	if available < amount {
+		return &InsufficientFundsError{AccountNo: accountNo, Available: available, Required: amount}
+	}
+	return nil
+}
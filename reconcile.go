@@ -0,0 +1,162 @@
+package temboplus
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ExpectedTransaction is a transaction your system expects to see on a statement
+type ExpectedTransaction struct {
+	TransactionRef string  // Your system reference
+	TransactionID  string  // TemboPlus transaction ID, if known
+	Amount         float64 // Expected amount; the sign is ignored
+}
+
+// ExpectedSource supplies the transactions expected on a statement for the
+// requested period, e.g. from your orders database
+type ExpectedSource interface {
+	ExpectedTransactions(ctx context.Context, req CollectionStatementRequest) ([]ExpectedTransaction, error)
+}
+
+// ExpectedSourceFunc adapts a function to ExpectedSource
+type ExpectedSourceFunc func(ctx context.Context, req CollectionStatementRequest) ([]ExpectedTransaction, error)
+
+// ExpectedTransactions implements ExpectedSource
+func (f ExpectedSourceFunc) ExpectedTransactions(ctx context.Context, req CollectionStatementRequest) ([]ExpectedTransaction, error) {
+	return f(ctx, req)
+}
+
+// ExpectedList is a fixed list of expected transactions usable as an ExpectedSource
+type ExpectedList []ExpectedTransaction
+
+// ExpectedTransactions implements ExpectedSource
+func (l ExpectedList) ExpectedTransactions(ctx context.Context, req CollectionStatementRequest) ([]ExpectedTransaction, error) {
+	return l, nil
+}
+
+// ReconcileOptions tunes matching
+type ReconcileOptions struct {
+	// Tolerance is the largest amount difference still treated as a match.
+	// Default: 0.005 (half a cent)
+	Tolerance float64
+}
+
+// ReconciledItem pairs an expected transaction with its statement entry
+type ReconciledItem struct {
+	Expected   ExpectedTransaction
+	Entry      CollectionStatementEntry
+	Difference float64 // Statement amount minus expected amount (absolute values)
+}
+
+// ReconciliationReport is the outcome of reconciling a statement
+type ReconciliationReport struct {
+	Matched               []ReconciledItem
+	AmountMismatches      []ReconciledItem
+	MissingInStatement    []ExpectedTransaction
+	UnexpectedInStatement []CollectionStatementEntry
+}
+
+// Balanced reports whether every expected transaction matched exactly and
+// the statement held nothing unexpected
+func (r *ReconciliationReport) Balanced() bool {
+	return len(r.AmountMismatches) == 0 && len(r.MissingInStatement) == 0 && len(r.UnexpectedInStatement) == 0
+}
+
+// String returns a one-line summary of the report
+func (r *ReconciliationReport) String() string {
+	return fmt.Sprintf("matched=%d amount_mismatch=%d missing=%d unexpected=%d",
+		len(r.Matched), len(r.AmountMismatches), len(r.MissingInStatement), len(r.UnexpectedInStatement))
+}
+
+// Reconcile matches statement entries to expected transactions. An entry
+// matches when its TranRefNo equals the expected TransactionRef or
+// TransactionID; matched pairs whose amounts differ by more than the
+// tolerance are reported as amount mismatches. Each entry matches at most
+// one expected transaction.
+func Reconcile(expected []ExpectedTransaction, entries []CollectionStatementEntry, opts ReconcileOptions) *ReconciliationReport {
+	if opts.Tolerance <= 0 {
+		opts.Tolerance = 0.005
+	}
+
+	// Index entries by reference, preserving statement order
+	byRef := make(map[string][]int)
+	for i, e := range entries {
+		if e.TranRefNo != "" {
+			byRef[e.TranRefNo] = append(byRef[e.TranRefNo], i)
+		}
+	}
+	used := make([]bool, len(entries))
+	take := func(ref string) (int, bool) {
+		if ref == "" {
+			return 0, false
+		}
+		for _, i := range byRef[ref] {
+			if !used[i] {
+				used[i] = true
+				return i, true
+			}
+		}
+		return 0, false
+	}
+
+	report := &ReconciliationReport{}
+	for _, exp := range expected {
+		i, ok := take(exp.TransactionRef)
+		if !ok {
+			i, ok = take(exp.TransactionID)
+		}
+		if !ok {
+			report.MissingInStatement = append(report.MissingInStatement, exp)
+			continue
+		}
+		item := ReconciledItem{
+			Expected:   exp,
+			Entry:      entries[i],
+			Difference: math.Abs(entries[i].Amount()) - math.Abs(exp.Amount),
+		}
+		if math.Abs(item.Difference) > opts.Tolerance {
+			report.AmountMismatches = append(report.AmountMismatches, item)
+		} else {
+			report.Matched = append(report.Matched, item)
+		}
+	}
+	for i, e := range entries {
+		if !used[i] {
+			report.UnexpectedInStatement = append(report.UnexpectedInStatement, e)
+		}
+	}
+	sort.SliceStable(report.AmountMismatches, func(a, b int) bool {
+		return math.Abs(report.AmountMismatches[a].Difference) > math.Abs(report.AmountMismatches[b].Difference)
+	})
+	return report
+}
+
+// ReconcileCollectionStatement fetches the collection statement for req and
+// reconciles it against the transactions supplied by source
+func (c *Client) ReconcileCollectionStatement(ctx context.Context, req CollectionStatementRequest, source ExpectedSource, opts ReconcileOptions) (*ReconciliationReport, error) {
+	return c.reconcile(ctx, req, source, opts, c.GetCollectionStatement)
+}
+
+// ReconcileMainStatement fetches the main statement for req and reconciles
+// it against the transactions supplied by source
+func (c *Client) ReconcileMainStatement(ctx context.Context, req CollectionStatementRequest, source ExpectedSource, opts ReconcileOptions) (*ReconciliationReport, error) {
+	return c.reconcile(ctx, req, source, opts, c.GetMainStatement)
+}
+
+func (c *Client) reconcile(ctx context.Context, req CollectionStatementRequest, source ExpectedSource, opts ReconcileOptions,
+	fetch func(context.Context, CollectionStatementRequest) ([]CollectionStatementEntry, error)) (*ReconciliationReport, error) {
+	if source == nil {
+		return nil, fmt.Errorf("expected transaction source is required")
+	}
+	expected, err := source.ExpectedTransactions(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load expected transactions: %w", err)
+	}
+	entries, err := fetch(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return Reconcile(expected, entries, opts), nil
+}
@@ -0,0 +1,399 @@
+package temboplus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned by a CollectionStore for unknown references
+var ErrNotFound = errors.New("not found")
+
+// CollectionRecord is a collection as remembered for refunds
+type CollectionRecord struct {
+	TransactionRef string    `json:"transactionRef"`
+	TransactionID  string    `json:"transactionId"`
+	MSISDN         string    `json:"msisdn"`
+	Channel        string    `json:"channel"`
+	Amount         float64   `json:"amount"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// RefundRecord is a refund payout linked to its original collection
+type RefundRecord struct {
+	TransactionRef         string            `json:"transactionRef"`
+	TransactionID          string            `json:"transactionId,omitempty"`
+	OriginalTransactionRef string            `json:"originalTransactionRef"`
+	AccountNo              string            `json:"accountNo"`
+	ServiceCode            string            `json:"serviceCode"`
+	MSISDN                 string            `json:"msisdn"`
+	Amount                 float64           `json:"amount"`
+	StatusCode             TransactionStatus `json:"statusCode,omitempty"`
+	CreatedAt              time.Time         `json:"createdAt"`
+}
+
+// counts reports whether the refund uses up refundable amount: pending and
+// accepted refunds do, failed ones do not
+func (r RefundRecord) counts() bool {
+	return !r.StatusCode.IsFailure()
+}
+
+// CollectionStore keeps collections and their refunds. Set
+// ClientConfig.CollectionStore to have CollectFromMobileMoney record every
+// submitted collection. Implementations must be safe for concurrent use.
+type CollectionStore interface {
+	SaveCollection(ctx context.Context, rec CollectionRecord) error
+	// GetCollection returns ErrNotFound for unknown references
+	GetCollection(ctx context.Context, transactionRef string) (CollectionRecord, error)
+	// SaveRefund adds a refund or replaces the one with the same TransactionRef
+	SaveRefund(ctx context.Context, rec RefundRecord) error
+	// GetRefund returns ErrNotFound for unknown references
+	GetRefund(ctx context.Context, transactionRef string) (RefundRecord, error)
+	// Refunds returns the refunds of a collection, oldest first
+	Refunds(ctx context.Context, originalTransactionRef string) ([]RefundRecord, error)
+}
+
+// MemoryCollectionStore is an in-memory CollectionStore
+type MemoryCollectionStore struct {
+	mu          sync.Mutex
+	collections map[string]CollectionRecord
+	refunds     map[string]RefundRecord
+}
+
+// NewMemoryCollectionStore returns an empty MemoryCollectionStore
+func NewMemoryCollectionStore() *MemoryCollectionStore {
+	return &MemoryCollectionStore{
+		collections: make(map[string]CollectionRecord),
+		refunds:     make(map[string]RefundRecord),
+	}
+}
+
+// SaveCollection implements CollectionStore
+func (s *MemoryCollectionStore) SaveCollection(ctx context.Context, rec CollectionRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.collections[rec.TransactionRef] = rec
+	return nil
+}
+
+// GetCollection implements CollectionStore
+func (s *MemoryCollectionStore) GetCollection(ctx context.Context, transactionRef string) (CollectionRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.collections[transactionRef]
+	if !ok {
+		return CollectionRecord{}, ErrNotFound
+	}
+	return rec, nil
+}
+
+// SaveRefund implements CollectionStore
+func (s *MemoryCollectionStore) SaveRefund(ctx context.Context, rec RefundRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refunds[rec.TransactionRef] = rec
+	return nil
+}
+
+// GetRefund implements CollectionStore
+func (s *MemoryCollectionStore) GetRefund(ctx context.Context, transactionRef string) (RefundRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.refunds[transactionRef]
+	if !ok {
+		return RefundRecord{}, ErrNotFound
+	}
+	return rec, nil
+}
+
+// Refunds implements CollectionStore
+func (s *MemoryCollectionStore) Refunds(ctx context.Context, originalTransactionRef string) ([]RefundRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []RefundRecord
+	for _, r := range s.refunds {
+		if r.OriginalTransactionRef == originalTransactionRef {
+			out = append(out, r)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+// OverRefundError is returned when a refund exceeds what is left to refund
+type OverRefundError struct {
+	TransactionRef string
+	Collected      float64
+	Refunded       float64
+	Requested      float64
+}
+
+// Refundable returns the amount that can still be refunded
+func (e *OverRefundError) Refundable() float64 {
+	return e.Collected - e.Refunded
+}
+
+func (e *OverRefundError) Error() string {
+	return fmt.Sprintf("refund of %.2f exceeds refundable amount %.2f for collection %s (collected %.2f, refunded %.2f)",
+		e.Requested, e.Refundable(), e.TransactionRef, e.Collected, e.Refunded)
+}
+
+// RefundRequest refunds all or part of a collection
+type RefundRequest struct {
+	OriginalTransactionRef string  // Reference of the collection to refund
+	Amount                 float64 // Amount to refund; 0 refunds everything not yet refunded
+	AccountNo              string  // Wallet to pay from; default: the collection wallet
+	RecipientNames         string  // Subscriber first and last names
+	Narration              string  // Default: "Refund of <OriginalTransactionRef>"
+	TransactionRef         string  // Must be unused; default: <OriginalTransactionRef>-R<n>
+	CallbackURL            string  // Webhook URL
+}
+
+// Refund pays a collection back to the subscriber it came from, using the
+// payout service of the collection's operator. The collection must be in
+// the CollectionStore and PAYMENT_ACCEPTED; pending and accepted refunds
+// count towards the collected amount, so it cannot be refunded twice. The
+// refund is saved in the store, linked to the collection by
+// OriginalTransactionRef. Track it with GetPaymentStatus or
+// UpdateRefundStatus; a refund whose submission failed without a response
+// stays PENDING_ACK, and counted, until one of them settles it.
+func (c *Client) Refund(ctx context.Context, req RefundRequest) (*MobileMoneyCollectionResponse, error) {
+	if c.collections == nil {
+		return nil, fmt.Errorf("refunds require ClientConfig.CollectionStore")
+	}
+	if req.OriginalTransactionRef == "" {
+		return nil, fmt.Errorf("originalTransactionRef is required")
+	}
+	if req.Amount < 0 {
+		return nil, fmt.Errorf("amount must not be negative")
+	}
+	if req.CallbackURL == "" {
+		return nil, fmt.Errorf("callbackUrl is required")
+	}
+
+	col, err := c.collections.GetCollection(ctx, req.OriginalTransactionRef)
+	if errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("collection %s not found in collection store", req.OriginalTransactionRef)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load collection %s: %w", req.OriginalTransactionRef, err)
+	}
+	status, err := c.GetCollectionStatus(ctx, PaymentStatusRequest{TransactionRef: col.TransactionRef, TransactionID: col.TransactionID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to check collection %s: %w", col.TransactionRef, err)
+	}
+	if !status.StatusCode.IsSuccess() {
+		return nil, fmt.Errorf("collection %s cannot be refunded: status is %s", col.TransactionRef, status.StatusCode)
+	}
+	service, err := c.refundService(col)
+	if err != nil {
+		return nil, err
+	}
+	if req.AccountNo == "" {
+		balance, err := c.GetCollectionBalance(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find collection wallet: %w", err)
+		}
+		req.AccountNo = balance.AccountNo
+	}
+
+	if req.Narration == "" {
+		req.Narration = "Refund of " + col.TransactionRef
+	}
+
+	refund, err := c.reserveRefund(ctx, col, req, service)
+	if err != nil {
+		return nil, err
+	}
+
+	channel, _ := c.registry.Channel(service)
+	country, _ := c.registry.Country(channel.Country)
+	payout := WalletToMobileRequest{
+		CountryCode:     country.Code,
+		AccountNo:       refund.AccountNo,
+		ServiceCode:     service,
+		Amount:          refund.Amount,
+		MSISDN:          col.MSISDN,
+		Narration:       req.Narration,
+		CurrencyCode:    country.Currency,
+		RecipientNames:  req.RecipientNames,
+		TransactionRef:  refund.TransactionRef,
+		TransactionDate: FormatTransactionDate(time.Now()),
+		CallbackURL:     req.CallbackURL,
+	}
+
+	// Only a refund that was never sent releases its reservation
+	err = c.validateWalletToMobileRequest(payout)
+	if err == nil {
+		err = c.checkSufficientFunds(ctx, payout.AccountNo, payout.Amount)
+	}
+	if err != nil {
+		refund.StatusCode = StatusGenericError
+		if serr := c.collections.SaveRefund(ctx, refund); serr != nil {
+			return nil, fmt.Errorf("%w (and releasing refund %s failed: %v)", err, refund.TransactionRef, serr)
+		}
+		return nil, err
+	}
+
+	response, err := c.submitWalletToMobile(ctx, payout)
+	if response == nil {
+		// A timeout or server error leaves the outcome unknown: the payout
+		// may have been made, so the refund stays PENDING_ACK until
+		// GetPaymentStatus or UpdateRefundStatus resolves it
+		return nil, fmt.Errorf("refund %s outcome unknown, left pending: %w", refund.TransactionRef, err)
+	}
+	refund.TransactionID = response.TransactionID
+	refund.StatusCode = response.StatusCode
+	if serr := c.collections.SaveRefund(ctx, refund); serr != nil && err == nil {
+		err = fmt.Errorf("refund submitted but not recorded: %w", serr)
+	}
+	return response, err
+}
+
+// reserveRefund checks the refundable amount and saves a pending refund.
+// refundMu serializes reservations within this client; stores shared by
+// several processes should enforce the limit themselves.
+func (c *Client) reserveRefund(ctx context.Context, col CollectionRecord, req RefundRequest, service string) (RefundRecord, error) {
+	c.refundMu.Lock()
+	defer c.refundMu.Unlock()
+
+	refunds, err := c.collections.Refunds(ctx, col.TransactionRef)
+	if err != nil {
+		return RefundRecord{}, fmt.Errorf("failed to load refunds of %s: %w", col.TransactionRef, err)
+	}
+	var refunded float64
+	for _, r := range refunds {
+		if r.counts() {
+			refunded += r.Amount
+		}
+	}
+	amount := req.Amount
+	if amount == 0 {
+		amount = col.Amount - refunded
+	}
+	if amount <= 0 || amount > col.Amount-refunded+1e-9 {
+		return RefundRecord{}, &OverRefundError{TransactionRef: col.TransactionRef, Collected: col.Amount, Refunded: refunded, Requested: amount}
+	}
+
+	// Saving under a used reference would replace that record and release
+	// its reservation while its payout may still be in flight
+	ref := req.TransactionRef
+	if ref != "" {
+		used, err := c.refundRefUsed(ctx, ref)
+		if err != nil {
+			return RefundRecord{}, err
+		}
+		if used {
+			return RefundRecord{}, fmt.Errorf("transactionRef %s is already used", ref)
+		}
+	} else {
+		for n := len(refunds) + 1; ; n++ {
+			ref = fmt.Sprintf("%s-R%d", col.TransactionRef, n)
+			used, err := c.refundRefUsed(ctx, ref)
+			if err != nil {
+				return RefundRecord{}, err
+			}
+			if !used {
+				break
+			}
+		}
+	}
+	refund := RefundRecord{
+		TransactionRef:         ref,
+		OriginalTransactionRef: col.TransactionRef,
+		AccountNo:              req.AccountNo,
+		ServiceCode:            service,
+		MSISDN:                 col.MSISDN,
+		Amount:                 amount,
+		StatusCode:             StatusPendingACK,
+		CreatedAt:              time.Now(),
+	}
+	if err := c.collections.SaveRefund(ctx, refund); err != nil {
+		return RefundRecord{}, fmt.Errorf("failed to record refund: %w", err)
+	}
+	return refund, nil
+}
+
+// refundRefUsed reports whether a refund or collection of any collection
+// already has the reference
+func (c *Client) refundRefUsed(ctx context.Context, ref string) (bool, error) {
+	_, err := c.collections.GetRefund(ctx, ref)
+	if err == nil {
+		return true, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return false, fmt.Errorf("failed to check refund reference %s: %w", ref, err)
+	}
+	_, err = c.collections.GetCollection(ctx, ref)
+	if err == nil {
+		return true, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return false, fmt.Errorf("failed to check refund reference %s: %w", ref, err)
+	}
+	return false, nil
+}
+
+// refundService returns the payout service code for the collection's
+// operator
+func (c *Client) refundService(col CollectionRecord) (string, error) {
+	op, ok := c.registry.OperatorForChannel(col.Channel)
+	if !ok {
+		p, err := c.registry.ParsePhoneNumber("TZ", col.MSISDN)
+		if err != nil || p.Operator == nil {
+			return "", fmt.Errorf("cannot determine refund service for channel %s", col.Channel)
+		}
+		op = *p.Operator
+	}
+	if op.B2CService == "" {
+		return "", fmt.Errorf("refunds are not supported for %s: no payout service", op.Name)
+	}
+	return op.B2CService, nil
+}
+
+// UpdateRefundStatus records the status of a refund from its webhook or a
+// GetPaymentStatus response. Illegal transitions are rejected.
+func (c *Client) UpdateRefundStatus(ctx context.Context, transactionRef string, status TransactionStatus) error {
+	if c.collections == nil {
+		return fmt.Errorf("refunds require ClientConfig.CollectionStore")
+	}
+	c.refundMu.Lock()
+	defer c.refundMu.Unlock()
+	refund, err := c.collections.GetRefund(ctx, transactionRef)
+	if err != nil {
+		return fmt.Errorf("failed to load refund %s: %w", transactionRef, err)
+	}
+	if !CanTransition(refund.StatusCode, status) {
+		return &TransitionError{TransactionRef: transactionRef, From: refund.StatusCode, To: status}
+	}
+	refund.StatusCode = status
+	return c.collections.SaveRefund(ctx, refund)
+}
+
+// recordCollection saves a submitted collection in the CollectionStore.
+// Failures go to ClientConfig.CollectionStoreError rather than the caller,
+// who would otherwise retry and charge the subscriber twice.
+func (c *Client) recordCollection(ctx context.Context, req MobileMoneyCollectionRequest, response *MobileMoneyCollectionResponse) {
+	if c.collections == nil || response == nil || response.StatusCode.IsFailure() {
+		return
+	}
+	rec := CollectionRecord{
+		TransactionRef: req.TransactionRef,
+		TransactionID:  response.TransactionID,
+		MSISDN:         req.MSISDN,
+		Channel:        req.Channel,
+		Amount:         req.Amount,
+		CreatedAt:      time.Now(),
+	}
+	if err := c.collections.SaveCollection(ctx, rec); err != nil {
+		if c.collectionsError != nil {
+			c.collectionsError(ctx, rec, err)
+			return
+		}
+		log.Printf("temboplus: collection %s submitted but not recorded: %v", rec.TransactionRef, err)
+	}
+}
@@ -0,0 +1,315 @@
+package temboplus_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	temboplus "github.com/techliana/temboplus-golang-sdk"
+	"github.com/techliana/temboplus-golang-sdk/temboplustest"
+)
+
+// acceptedCollection collects amount through srv and waits until the fake
+// server has accepted it
+func acceptedCollection(t *testing.T, srv *temboplustest.Server, client *temboplus.Client, ref string, amount float64) {
+	t.Helper()
+	_, err := client.CollectFromMobileMoney(context.Background(), temboplus.MobileMoneyCollectionRequest{
+		MSISDN:          "255715123456",
+		Channel:         temboplus.ChannelAuto,
+		Amount:          amount,
+		Narration:       "Order " + ref,
+		TransactionRef:  ref,
+		TransactionDate: temboplus.FormatTransactionDate(time.Now()),
+		CallbackURL:     "https://example.com/webhooks/temboplus",
+	})
+	if err != nil {
+		t.Fatalf("collection failed: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if tx, ok := srv.Transaction(ref); ok && tx.StatusCode == temboplus.StatusPaymentAccepted {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("collection %s was not accepted", ref)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// refundable sums what is left to refund of a collection, counting pending
+// and accepted refunds as the client does
+func refundable(t *testing.T, store temboplus.CollectionStore, ref string, collected float64) float64 {
+	t.Helper()
+	refunds, err := store.Refunds(context.Background(), ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range refunds {
+		if !r.StatusCode.IsFailure() {
+			collected -= r.Amount
+		}
+	}
+	return collected
+}
+
+func TestRefundReservation(t *testing.T) {
+	const collected = 5000
+
+	type step struct {
+		amount     float64                     // 0 refunds the remainder
+		ref        string                      // refund reference; default ORDER-1-R<step>
+		payout     *temboplustest.Scenario     // scripted answer to the payout request
+		balance    float64                     // collection wallet balance before the step; 0 leaves it
+		resolve    temboplus.TransactionStatus // passed to UpdateRefundStatus after the step
+		wantErr    string                      // substring of the expected error; empty for success
+		wantStatus temboplus.TransactionStatus // stored status of the refund; empty when none is saved
+	}
+	serverError := temboplustest.ServerError
+	reject := temboplustest.Reject
+
+	tests := []struct {
+		name           string
+		steps          []step
+		wantRefundable float64
+	}{
+		{
+			name:  "full refund",
+			steps: []step{{wantStatus: temboplus.StatusPendingACK}},
+		},
+		{
+			name: "partial refunds up to the collected amount",
+			steps: []step{
+				{amount: 2000, wantStatus: temboplus.StatusPendingACK},
+				{amount: 3000, wantStatus: temboplus.StatusPendingACK},
+			},
+		},
+		{
+			name: "second full refund is an over-refund",
+			steps: []step{
+				{wantStatus: temboplus.StatusPendingACK},
+				{wantErr: "exceeds refundable amount"},
+			},
+		},
+		{
+			name:           "over-refund is not reserved",
+			steps:          []step{{amount: 6000, wantErr: "exceeds refundable amount"}},
+			wantRefundable: collected,
+		},
+		{
+			name: "rejected payout releases its reservation",
+			steps: []step{
+				{payout: &reject, wantErr: "PAYMENT_REJECTED", wantStatus: temboplus.StatusPaymentRejected},
+				{wantStatus: temboplus.StatusPendingACK},
+			},
+		},
+		{
+			name: "server error keeps the reservation",
+			steps: []step{
+				{amount: 1000, payout: &serverError, wantErr: "outcome unknown", wantStatus: temboplus.StatusPendingACK},
+				{wantStatus: temboplus.StatusPendingACK},
+				{amount: 1, wantErr: "exceeds refundable amount"},
+			},
+		},
+		{
+			name: "resolving an ambiguous refund as failed releases it",
+			steps: []step{
+				{amount: 1000, payout: &serverError, resolve: temboplus.StatusPaymentRejected, wantErr: "outcome unknown", wantStatus: temboplus.StatusPaymentRejected},
+				{amount: 5000, wantStatus: temboplus.StatusPendingACK},
+			},
+		},
+		{
+			name: "reused reference is rejected",
+			steps: []step{
+				{amount: 2000, ref: "R", wantStatus: temboplus.StatusPendingACK},
+				{amount: 3000, ref: "R", wantErr: "already used", wantStatus: temboplus.StatusPendingACK},
+			},
+			wantRefundable: 3000,
+		},
+		{
+			name: "rejected duplicate keeps the first reservation",
+			steps: []step{
+				{amount: 2000, ref: "R", wantStatus: temboplus.StatusPendingACK},
+				{amount: 3000, ref: "R", payout: &reject, wantErr: "already used", wantStatus: temboplus.StatusPendingACK},
+				{amount: 3001, wantErr: "exceeds refundable amount"},
+			},
+			wantRefundable: 3000,
+		},
+		{
+			name:           "collection reference is rejected",
+			steps:          []step{{amount: 1000, ref: "ORDER-1", wantErr: "already used"}},
+			wantRefundable: collected,
+		},
+		{
+			name:           "preflight failure releases its reservation",
+			steps:          []step{{balance: 100, wantErr: "insufficient funds", wantStatus: temboplus.StatusGenericError}},
+			wantRefundable: collected,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := temboplustest.NewServer()
+			defer srv.Close()
+			srv.AutoWebhook = false
+			store := temboplus.NewMemoryCollectionStore()
+			client := temboplus.NewClient(temboplus.ClientConfig{
+				BaseURL:          srv.URL,
+				CollectionStore:  store,
+				BalancePreflight: true,
+			})
+			ctx := context.Background()
+			acceptedCollection(t, srv, client, "ORDER-1", collected)
+
+			for i, st := range tt.steps {
+				if st.balance > 0 {
+					srv.SetBalance(temboplustest.CollectionAccountNo, st.balance)
+				}
+				if st.payout != nil {
+					srv.Script(temboplus.EndpointPaymentWalletToMobile, *st.payout)
+				}
+				ref := st.ref
+				if ref == "" {
+					ref = fmt.Sprintf("ORDER-1-R%d", i+1)
+				}
+				_, err := client.Refund(ctx, temboplus.RefundRequest{
+					OriginalTransactionRef: "ORDER-1",
+					Amount:                 st.amount,
+					RecipientNames:         "Asha Juma",
+					TransactionRef:         ref,
+					CallbackURL:            "https://example.com/webhooks/temboplus",
+				})
+				switch {
+				case st.wantErr == "" && err != nil:
+					t.Fatalf("step %d: unexpected error: %v", i+1, err)
+				case st.wantErr != "" && (err == nil || !strings.Contains(err.Error(), st.wantErr)):
+					t.Fatalf("step %d: error = %v, want %q", i+1, err, st.wantErr)
+				}
+				if st.resolve != "" {
+					if err := client.UpdateRefundStatus(ctx, ref, st.resolve); err != nil {
+						t.Fatalf("step %d: UpdateRefundStatus: %v", i+1, err)
+					}
+				}
+
+				rec, err := store.GetRefund(ctx, ref)
+				switch {
+				case st.wantStatus == "" && !errors.Is(err, temboplus.ErrNotFound):
+					t.Fatalf("step %d: refund saved with status %s, want none", i+1, rec.StatusCode)
+				case st.wantStatus != "" && err != nil:
+					t.Fatalf("step %d: refund not saved: %v", i+1, err)
+				case rec.StatusCode != st.wantStatus:
+					t.Fatalf("step %d: status = %s, want %s", i+1, rec.StatusCode, st.wantStatus)
+				}
+			}
+
+			if got := refundable(t, store, "ORDER-1", collected); got != tt.wantRefundable {
+				t.Errorf("refundable = %.2f, want %.2f", got, tt.wantRefundable)
+			}
+		})
+	}
+}
+
+func TestRefundOverRefundError(t *testing.T) {
+	srv := temboplustest.NewServer()
+	defer srv.Close()
+	srv.AutoWebhook = false
+	client := temboplus.NewClient(temboplus.ClientConfig{BaseURL: srv.URL, CollectionStore: temboplus.NewMemoryCollectionStore()})
+	acceptedCollection(t, srv, client, "ORDER-1", 5000)
+
+	req := temboplus.RefundRequest{OriginalTransactionRef: "ORDER-1", Amount: 3000, RecipientNames: "Asha Juma", CallbackURL: "https://example.com/webhooks/temboplus"}
+	if _, err := client.Refund(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	_, err := client.Refund(context.Background(), req)
+	var over *temboplus.OverRefundError
+	if !errors.As(err, &over) {
+		t.Fatalf("error = %v, want *OverRefundError", err)
+	}
+	if over.Refundable() != 2000 || over.Requested != 3000 {
+		t.Errorf("refundable %.2f requested %.2f, want 2000 and 3000", over.Refundable(), over.Requested)
+	}
+}
+
+func TestRefundDefaultReferenceSkipsUsedOnes(t *testing.T) {
+	srv := temboplustest.NewServer()
+	defer srv.Close()
+	srv.AutoWebhook = false
+	store := temboplus.NewMemoryCollectionStore()
+	client := temboplus.NewClient(temboplus.ClientConfig{BaseURL: srv.URL, CollectionStore: store})
+	acceptedCollection(t, srv, client, "ORDER-1", 5000)
+	ctx := context.Background()
+
+	// A caller-chosen reference that the next default would have used
+	req := temboplus.RefundRequest{OriginalTransactionRef: "ORDER-1", Amount: 1000, RecipientNames: "Asha Juma", TransactionRef: "ORDER-1-R2", CallbackURL: "https://example.com/webhooks/temboplus"}
+	if _, err := client.Refund(ctx, req); err != nil {
+		t.Fatal(err)
+	}
+	req.TransactionRef = ""
+	resp, err := client.Refund(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.TransactionRef != "ORDER-1-R3" {
+		t.Errorf("default reference = %s, want ORDER-1-R3", resp.TransactionRef)
+	}
+	if got := refundable(t, store, "ORDER-1", 5000); got != 3000 {
+		t.Errorf("refundable = %.2f, want 3000", got)
+	}
+}
+
+func TestRefundRequiresAcceptedCollection(t *testing.T) {
+	srv := temboplustest.NewServer()
+	defer srv.Close()
+	srv.AutoWebhook = false
+	srv.WebhookDelay = 200 * time.Millisecond // still pending when refunded
+	client := temboplus.NewClient(temboplus.ClientConfig{BaseURL: srv.URL, CollectionStore: temboplus.NewMemoryCollectionStore()})
+	_, err := client.CollectFromMobileMoney(context.Background(), temboplus.MobileMoneyCollectionRequest{
+		MSISDN: "255715123456", Channel: temboplus.ChannelAuto, Amount: 5000, Narration: "Order",
+		TransactionRef: "ORDER-1", TransactionDate: temboplus.FormatTransactionDate(time.Now()),
+		CallbackURL: "https://example.com/webhooks/temboplus",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.Refund(context.Background(), temboplus.RefundRequest{OriginalTransactionRef: "ORDER-1", CallbackURL: "https://example.com/webhooks/temboplus"})
+	if err == nil || !strings.Contains(err.Error(), "cannot be refunded") {
+		t.Fatalf("error = %v, want collection not refundable", err)
+	}
+}
+
+// failingStore fails every SaveCollection
+type failingStore struct {
+	*temboplus.MemoryCollectionStore
+}
+
+func (failingStore) SaveCollection(context.Context, temboplus.CollectionRecord) error {
+	return errors.New("disk full")
+}
+
+func TestCollectionStoreFailureDoesNotFailCollection(t *testing.T) {
+	srv := temboplustest.NewServer()
+	defer srv.Close()
+	srv.AutoWebhook = false
+	var reported error
+	client := temboplus.NewClient(temboplus.ClientConfig{
+		BaseURL:         srv.URL,
+		CollectionStore: failingStore{temboplus.NewMemoryCollectionStore()},
+		CollectionStoreError: func(ctx context.Context, rec temboplus.CollectionRecord, err error) {
+			reported = err
+		},
+	})
+	resp, err := client.CollectFromMobileMoney(context.Background(), temboplus.MobileMoneyCollectionRequest{
+		MSISDN: "255715123456", Channel: temboplus.ChannelAuto, Amount: 5000, Narration: "Order",
+		TransactionRef: "ORDER-1", TransactionDate: temboplus.FormatTransactionDate(time.Now()),
+		CallbackURL: "https://example.com/webhooks/temboplus",
+	})
+	if err != nil || resp == nil || resp.StatusCode != temboplus.StatusPendingACK {
+		t.Fatalf("CollectFromMobileMoney = %v, %v; want PENDING_ACK and no error", resp, err)
+	}
+	if reported == nil {
+		t.Error("store failure was not reported to CollectionStoreError")
+	}
+}
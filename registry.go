@@ -0,0 +1,414 @@
+package temboplus
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Direction is the direction of money movement for a channel
+type Direction string
+
+const (
+	DirectionC2B Direction = "C2B" // Collections from subscribers
+	DirectionB2C Direction = "B2C" // Payouts to subscribers or bank accounts
+)
+
+// Currency describes a currency supported by the registry
+type Currency struct {
+	Code       string `json:"code"`       // ISO 4217 code, e.g. TZS
+	Name       string `json:"name"`       // Display name
+	MinorUnits int    `json:"minorUnits"` // Decimal places allowed in amounts
+}
+
+// Country describes a country and its mobile numbering rules
+type Country struct {
+	Code           string   `json:"code"`           // ISO 3166-1 alpha-2 code, e.g. TZ
+	Name           string   `json:"name"`           // Display name
+	DialCode       string   `json:"dialCode"`       // International dialing code, e.g. 255
+	Currency       string   `json:"currency"`       // Default currency code
+	NumberLength   int      `json:"numberLength"`   // Digits in a national mobile number (without dial code or trunk 0)
+	MobilePrefixes []string `json:"mobilePrefixes"` // Leading digits of national mobile numbers
+}
+
+// PaymentChannel describes a C2B channel or B2C service code
+type PaymentChannel struct {
+	Code      string    `json:"code"`           // e.g. TZ-TIGO-C2B
+	Country   string    `json:"country"`        // Country code
+	Operator  string    `json:"operator"`       // Operator or network name
+	Direction Direction `json:"direction"`      // C2B or B2C
+	Bank      bool      `json:"bank,omitempty"` // Destination is a bank account, not an MSISDN
+
+	// Per-transaction limits; 0 means unbounded
+	MinAmount float64     `json:"minAmount,omitempty"`
+	MaxAmount float64     `json:"maxAmount,omitempty"`
+	Fees      FeeSchedule `json:"fees,omitzero"` // Charges used by EstimateFee
+}
+
+// Registry holds the countries, currencies, channels, operators and banks
+// the SDK accepts.
+// It is safe for concurrent use. Add corridors with the Register methods or
+// LoadRegistry instead of changing code.
+type Registry struct {
+	mu         sync.RWMutex
+	countries  map[string]Country
+	currencies map[string]Currency
+	channels   map[string]PaymentChannel
+	operators  map[string][]Operator
+	banks      map[string]Bank
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		countries:  make(map[string]Country),
+		currencies: make(map[string]Currency),
+		channels:   make(map[string]PaymentChannel),
+		operators:  make(map[string][]Operator),
+		banks:      make(map[string]Bank),
+	}
+}
+
+// defaultRegistry backs DefaultRegistry
+var defaultRegistry = newDefaultRegistry()
+
+// DefaultRegistry returns the registry used by clients that do not set
+// ClientConfig.Registry. It ships with Tanzania channels, operators and
+// banks plus the Kenya, Uganda and Rwanda countries and currencies, ready
+// for channels to be registered.
+func DefaultRegistry() *Registry {
+	return defaultRegistry
+}
+
+func newDefaultRegistry() *Registry {
+	r := NewRegistry()
+	for _, c := range []Currency{
+		{Code: "TZS", Name: "Tanzanian Shilling", MinorUnits: 2},
+		{Code: "KES", Name: "Kenyan Shilling", MinorUnits: 2},
+		{Code: "UGX", Name: "Ugandan Shilling", MinorUnits: 0},
+		{Code: "RWF", Name: "Rwandan Franc", MinorUnits: 0},
+	} {
+		r.RegisterCurrency(c)
+	}
+	for _, c := range []Country{
+		{Code: "TZ", Name: "Tanzania", DialCode: "255", Currency: "TZS", NumberLength: 9, MobilePrefixes: []string{"6", "7"}},
+		{Code: "KE", Name: "Kenya", DialCode: "254", Currency: "KES", NumberLength: 9, MobilePrefixes: []string{"1", "7"}},
+		{Code: "UG", Name: "Uganda", DialCode: "256", Currency: "UGX", NumberLength: 9, MobilePrefixes: []string{"7"}},
+		{Code: "RW", Name: "Rwanda", DialCode: "250", Currency: "RWF", NumberLength: 9, MobilePrefixes: []string{"7"}},
+	} {
+		r.RegisterCountry(c)
+	}
+	for _, c := range []PaymentChannel{
+		{Code: ChannelTZTigoC2B, Country: "TZ", Operator: "Tigo", Direction: DirectionC2B},
+		{Code: ChannelTZAirtelC2B, Country: "TZ", Operator: "Airtel", Direction: DirectionC2B},
+		{Code: ChannelTZHalotelC2B, Country: "TZ", Operator: "Halotel", Direction: DirectionC2B},
+		{Code: ServiceTZTigoB2C, Country: "TZ", Operator: "Tigo", Direction: DirectionB2C},
+		{Code: ServiceTZAirtelB2C, Country: "TZ", Operator: "Airtel", Direction: DirectionB2C},
+		{Code: ServiceTZBankB2C, Country: "TZ", Operator: "Bank", Direction: DirectionB2C, Bank: true},
+	} {
+		r.RegisterChannel(c)
+	}
+	for _, op := range tanzaniaOperators {
+		r.RegisterOperator(op)
+	}
+	for _, b := range tanzaniaBanks {
+		r.RegisterBank(b)
+	}
+	return r
+}
+
+// Clone returns an independent copy of r, e.g. to extend DefaultRegistry
+// without affecting other clients
+func (r *Registry) Clone() *Registry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := NewRegistry()
+	for k, v := range r.countries {
+		v.MobilePrefixes = append([]string(nil), v.MobilePrefixes...)
+		out.countries[k] = v
+	}
+	for k, v := range r.currencies {
+		out.currencies[k] = v
+	}
+	for k, v := range r.channels {
+		v.Fees = v.Fees.clone()
+		out.channels[k] = v
+	}
+	for k, ops := range r.operators {
+		for _, op := range ops {
+			op.Prefixes = append([]string(nil), op.Prefixes...)
+			out.operators[k] = append(out.operators[k], op)
+		}
+	}
+	for k, v := range r.banks {
+		v.AccountLengths = append([]int(nil), v.AccountLengths...)
+		out.banks[k] = v
+	}
+	return out
+}
+
+// RegisterCurrency adds or replaces a currency
+func (r *Registry) RegisterCurrency(c Currency) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c.Code = strings.ToUpper(c.Code)
+	r.currencies[c.Code] = c
+}
+
+// RegisterCountry adds or replaces a country
+func (r *Registry) RegisterCountry(c Country) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c.Code = strings.ToUpper(c.Code)
+	c.Currency = strings.ToUpper(c.Currency)
+	r.countries[c.Code] = c
+}
+
+// RegisterChannel adds or replaces a channel or service code
+func (r *Registry) RegisterChannel(c PaymentChannel) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c.Country = strings.ToUpper(c.Country)
+	c.Fees = c.Fees.clone()
+	r.channels[c.Code] = c
+}
+
+// SetChannelLimits sets the per-transaction limits of a registered channel
+// or service code. Zero leaves a bound unbounded.
+func (r *Registry) SetChannelLimits(code string, minAmount, maxAmount float64) error {
+	if minAmount < 0 || maxAmount < 0 || (maxAmount > 0 && minAmount > maxAmount) {
+		return fmt.Errorf("invalid limits for %s: min %v, max %v", code, minAmount, maxAmount)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.channels[code]
+	if !ok {
+		return fmt.Errorf("unknown channel: %s", code)
+	}
+	c.MinAmount, c.MaxAmount = minAmount, maxAmount
+	r.channels[code] = c
+	return nil
+}
+
+// AmountLimits returns the per-transaction limits of a channel or service
+// code as set by SetChannelLimits or LoadRegistry. Zero means no limit.
+// Operator catalog amounts are informational and are not applied.
+func (r *Registry) AmountLimits(code string) (minAmount, maxAmount float64) {
+	c, ok := r.Channel(code)
+	if !ok {
+		return 0, 0
+	}
+	return c.MinAmount, c.MaxAmount
+}
+
+// ValidateChannelAmount checks an amount against the limits of a channel
+// or service code
+func (r *Registry) ValidateChannelAmount(code string, amount float64) error {
+	minAmount, maxAmount := r.AmountLimits(code)
+	if minAmount > 0 && amount < minAmount {
+		return fmt.Errorf("amount %.2f is below the minimum of %.2f for %s", amount, minAmount, code)
+	}
+	if maxAmount > 0 && amount > maxAmount {
+		return fmt.Errorf("amount %.2f exceeds the maximum of %.2f for %s", amount, maxAmount, code)
+	}
+	return nil
+}
+
+// registryFile is the JSON format read by LoadRegistry
+type registryFile struct {
+	Currencies []Currency       `json:"currencies"`
+	Countries  []Country        `json:"countries"`
+	Channels   []PaymentChannel `json:"channels"`
+	Operators  []Operator       `json:"operators"`
+	Banks      []Bank           `json:"banks"`
+}
+
+// LoadRegistry merges currencies, countries, channels, operators and banks
+// from JSON into r:
+//
+//	{"currencies": [...], "countries": [...], "channels": [...], "operators": [...], "banks": [...]}
+func (r *Registry) LoadRegistry(reader io.Reader) error {
+	var f registryFile
+	if err := json.NewDecoder(reader).Decode(&f); err != nil {
+		return fmt.Errorf("failed to parse registry: %w", err)
+	}
+	for _, c := range f.Currencies {
+		if c.Code == "" {
+			return fmt.Errorf("registry currency without code")
+		}
+		r.RegisterCurrency(c)
+	}
+	for _, c := range f.Countries {
+		if c.Code == "" || c.DialCode == "" {
+			return fmt.Errorf("registry country %q requires code and dialCode", c.Code)
+		}
+		r.RegisterCountry(c)
+	}
+	for _, c := range f.Channels {
+		if c.Code == "" || c.Country == "" || (c.Direction != DirectionC2B && c.Direction != DirectionB2C) {
+			return fmt.Errorf("registry channel %q requires code, country and direction C2B or B2C", c.Code)
+		}
+		r.RegisterChannel(c)
+	}
+	for _, op := range f.Operators {
+		if op.Name == "" || op.Country == "" || len(op.Prefixes) == 0 {
+			return fmt.Errorf("registry operator %q requires name, country and prefixes", op.Name)
+		}
+		r.RegisterOperator(op)
+	}
+	for _, b := range f.Banks {
+		if b.BIC == "" || b.Country == "" {
+			return fmt.Errorf("registry bank %q requires bic and country", b.BIC)
+		}
+		r.RegisterBank(b)
+	}
+	return nil
+}
+
+// Country looks up a country by code
+func (r *Registry) Country(code string) (Country, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.countries[strings.ToUpper(code)]
+	return c, ok
+}
+
+// Currency looks up a currency by code
+func (r *Registry) Currency(code string) (Currency, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.currencies[strings.ToUpper(code)]
+	return c, ok
+}
+
+// Channel looks up a channel or service code
+func (r *Registry) Channel(code string) (PaymentChannel, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.channels[code]
+	return c, ok
+}
+
+// Channels returns the channel codes for a country and direction, sorted.
+// An empty country matches all countries.
+func (r *Registry) Channels(country string, direction Direction) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var codes []string
+	for code, c := range r.channels {
+		if c.Direction == direction && (country == "" || c.Country == strings.ToUpper(country)) {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// FormatMSISDN normalizes a phone number to international format without
+// "+" (e.g. 255712345678) using the country's dial code
+func (r *Registry) FormatMSISDN(countryCode, phoneNumber string) (string, error) {
+	country, ok := r.Country(countryCode)
+	if !ok {
+		return "", fmt.Errorf("unsupported countryCode: %s", countryCode)
+	}
+	digits := strings.TrimPrefix(phoneNumber, "+")
+	if strings.HasPrefix(digits, "0") && len(digits) == country.NumberLength+1 {
+		digits = digits[1:]
+	}
+	if len(digits) == country.NumberLength {
+		digits = country.DialCode + digits
+	}
+	return digits, r.ValidateMSISDN(countryCode, digits)
+}
+
+// ValidateMSISDN checks an international-format MSISDN against the
+// country's dial code, number length and mobile prefixes
+func (r *Registry) ValidateMSISDN(countryCode, msisdn string) error {
+	country, ok := r.Country(countryCode)
+	if !ok {
+		return fmt.Errorf("unsupported countryCode: %s", countryCode)
+	}
+	for _, ch := range msisdn {
+		if ch < '0' || ch > '9' {
+			return fmt.Errorf("invalid MSISDN %s: must contain digits only", msisdn)
+		}
+	}
+	if !strings.HasPrefix(msisdn, country.DialCode) {
+		return fmt.Errorf("MSISDN should start with country code %s for %s: %s", country.DialCode, country.Name, msisdn)
+	}
+	national := msisdn[len(country.DialCode):]
+	if country.NumberLength > 0 && len(national) != country.NumberLength {
+		return fmt.Errorf("invalid MSISDN length for %s: %s", country.Name, msisdn)
+	}
+	if len(country.MobilePrefixes) > 0 {
+		for _, p := range country.MobilePrefixes {
+			if strings.HasPrefix(national, p) {
+				return nil
+			}
+		}
+		return fmt.Errorf("MSISDN %s is not a %s mobile number", msisdn, country.Name)
+	}
+	return nil
+}
+
+// ValidateAmount checks that amount is positive and has no more decimal
+// places than the currency allows
+func (r *Registry) ValidateAmount(currencyCode string, amount float64) error {
+	if amount <= 0 {
+		return fmt.Errorf("amount must be greater than 0")
+	}
+	currency, ok := r.Currency(currencyCode)
+	if !ok {
+		return fmt.Errorf("unsupported currencyCode: %s", currencyCode)
+	}
+	scaled := amount * math.Pow10(currency.MinorUnits)
+	if math.Abs(scaled-math.Round(scaled)) > 1e-6 {
+		return fmt.Errorf("amount %v has more than %d decimal places allowed for %s", amount, currency.MinorUnits, currency.Code)
+	}
+	return nil
+}
+
+// validateCollection checks the channel, MSISDN and amount of a collection
+func (r *Registry) validateCollection(req MobileMoneyCollectionRequest) error {
+	channel, ok := r.Channel(req.Channel)
+	if !ok || channel.Direction != DirectionC2B {
+		return fmt.Errorf("invalid channel: %s. Supported channels: %v", req.Channel, r.Channels("", DirectionC2B))
+	}
+	if err := r.ValidateMSISDN(channel.Country, req.MSISDN); err != nil {
+		return err
+	}
+	country, _ := r.Country(channel.Country)
+	if err := r.ValidateAmount(country.Currency, req.Amount); err != nil {
+		return err
+	}
+	return r.ValidateChannelAmount(channel.Code, req.Amount)
+}
+
+// validatePayout checks the country, currency, service code, destination
+// and amount of a wallet payout
+func (r *Registry) validatePayout(req WalletToMobileRequest) error {
+	country, ok := r.Country(req.CountryCode)
+	if !ok {
+		return fmt.Errorf("unsupported countryCode: %s", req.CountryCode)
+	}
+	if _, ok := r.Currency(req.CurrencyCode); !ok || !strings.EqualFold(req.CurrencyCode, country.Currency) {
+		return fmt.Errorf("unsupported currencyCode: %s", req.CurrencyCode)
+	}
+	service, ok := r.Channel(req.ServiceCode)
+	if !ok || service.Direction != DirectionB2C || service.Country != country.Code {
+		return fmt.Errorf("invalid serviceCode: %s. Supported services: %v", req.ServiceCode, r.Channels(country.Code, DirectionB2C))
+	}
+	if !service.Bank {
+		if err := r.ValidateMSISDN(country.Code, req.MSISDN); err != nil {
+			return err
+		}
+	}
+	if err := r.ValidateAmount(req.CurrencyCode, req.Amount); err != nil {
+		return err
+	}
+	return r.ValidateChannelAmount(service.Code, req.Amount)
+}
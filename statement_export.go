@@ -0,0 +1,477 @@
+package temboplus
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// StatementFormat is a statement export format
+type StatementFormat string
+
+const (
+	StatementFormatCSV       StatementFormat = "csv"
+	StatementFormatJSONLines StatementFormat = "jsonl"
+	StatementFormatOFX       StatementFormat = "ofx"
+	StatementFormatCAMT053   StatementFormat = "camt053"
+)
+
+const (
+	defaultStatementCurrency = "TZS"
+	camt053Namespace         = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+	camtDateTimeLayout       = "2006-01-02T15:04:05"
+	ofxBankID                = "TEMBOPLUS"
+	ofxNameMaxLen            = 32
+	ofxDateLayout            = "20060102150405.000"
+)
+
+// StatementAccount describes the account a statement belongs to
+type StatementAccount struct {
+	AccountNo   string
+	AccountName string
+	Currency    string    // Default: TZS
+	From        time.Time // Statement period; defaults to the first entry date
+	To          time.Time // Statement period; defaults to the last entry date
+}
+
+// StatementAccountFromBalance takes account metadata from a balance response
+// such as GetMainBalance or GetCollectionBalance
+func StatementAccountFromBalance(b *CollectionBalanceResponse) StatementAccount {
+	if b == nil {
+		return StatementAccount{Currency: defaultStatementCurrency}
+	}
+	return StatementAccount{
+		AccountNo:   b.AccountNo,
+		AccountName: b.AccountName,
+		Currency:    defaultStatementCurrency,
+	}
+}
+
+// ExportStatement writes entries to w in the given format
+func ExportStatement(w io.Writer, format StatementFormat, account StatementAccount, entries []CollectionStatementEntry) error {
+	switch format {
+	case StatementFormatCSV:
+		return ExportStatementCSV(w, entries)
+	case StatementFormatJSONLines:
+		return ExportStatementJSONLines(w, entries)
+	case StatementFormatOFX:
+		return ExportStatementOFX(w, account, entries)
+	case StatementFormatCAMT053:
+		return ExportStatementCAMT053(w, account, entries)
+	default:
+		return fmt.Errorf("unsupported statement format: %s", format)
+	}
+}
+
+// ExportStatementCSV writes entries as CSV with a header row
+func ExportStatementCSV(w io.Writer, entries []CollectionStatementEntry) error {
+	cw := csv.NewWriter(w)
+	header := []string{"accountNo", "txnDate", "valueDate", "tranRefNo", "narration", "debitOrCredit", "amountCredited", "amountDebited", "balance"}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := cw.Write([]string{
+			e.AccountNo,
+			e.TxnDate,
+			e.ValueDate,
+			e.TranRefNo,
+			e.Narration,
+			e.DebitOrCredit,
+			formatNullableAmount(e.AmountCredited),
+			formatNullableAmount(e.AmountDebited),
+			formatAmount(e.Balance),
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ExportStatementJSONLines writes one JSON object per entry
+func ExportStatementJSONLines(w io.Writer, entries []CollectionStatementEntry) error {
+	enc := json.NewEncoder(w)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportStatementOFX writes entries as an OFX 2.2 bank statement
+func ExportStatementOFX(w io.Writer, account StatementAccount, entries []CollectionStatementEntry) error {
+	account, summary := prepareStatementExport(account, entries)
+	now := time.Now().In(tanzaniaLocation)
+
+	doc := ofxDocument{
+		SignOn: ofxSignOn{
+			Status:   ofxOK,
+			DTServer: ofxDate(now),
+			Language: "ENG",
+		},
+		Bank: ofxBank{TrnRs: ofxStmtTrnRs{
+			TrnUID: "1",
+			Status: ofxOK,
+			StmtRs: ofxStmtRs{
+				CurDef: account.Currency,
+				Account: ofxAccount{
+					BankID:   ofxBankID,
+					AcctID:   account.AccountNo,
+					AcctType: "CHECKING",
+				},
+				TranList: ofxTranList{
+					DTStart: ofxDate(account.From),
+					DTEnd:   ofxDate(account.To),
+				},
+				LedgerBal: ofxBalance{Amount: formatAmount(summary.ClosingBalance), AsOf: ofxDate(account.To)},
+			},
+		}},
+	}
+	for i, e := range entries {
+		amount := e.Amount()
+		trnType := "CREDIT"
+		if amount < 0 {
+			trnType = "DEBIT"
+		}
+		name := e.Narration
+		if r := []rune(name); len(r) > ofxNameMaxLen {
+			name = string(r[:ofxNameMaxLen])
+		}
+		doc.Bank.TrnRs.StmtRs.TranList.Transactions = append(doc.Bank.TrnRs.StmtRs.TranList.Transactions, ofxTransaction{
+			TrnType:  trnType,
+			DTPosted: ofxDate(entryTime(e, account.From)),
+			TrnAmt:   formatAmount(amount),
+			FITID:    entryID(e, i),
+			Name:     name,
+			Memo:     e.Narration,
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header+`<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>`+"\n"); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// ExportStatementCAMT053 writes entries as an ISO 20022 camt.053.001.02
+// bank-to-customer statement
+func ExportStatementCAMT053(w io.Writer, account StatementAccount, entries []CollectionStatementEntry) error {
+	account, summary := prepareStatementExport(account, entries)
+	now := time.Now().In(tanzaniaLocation)
+	id := fmt.Sprintf("%s-%s-%s", account.AccountNo, account.From.Format("20060102"), account.To.Format("20060102"))
+
+	stmt := camtStatement{
+		ID:      id,
+		CreDtTm: now.Format(camtDateTimeLayout),
+		FromTo:  camtFromTo{From: account.From.Format(camtDateTimeLayout), To: account.To.Format(camtDateTimeLayout)},
+		Account: camtAccount{ID: account.AccountNo, Currency: account.Currency, Name: account.AccountName},
+		Balances: []camtBalance{
+			newCamtBalance("OPBD", summary.OpeningBalance, account.Currency, account.From),
+			newCamtBalance("CLBD", summary.ClosingBalance, account.Currency, account.To),
+		},
+	}
+	for i, e := range entries {
+		amount := e.Amount()
+		ref := entryID(e, i)
+		entry := camtEntry{
+			Ref:       ref,
+			Amount:    camtAmount{Currency: account.Currency, Value: formatAmount(abs(amount))},
+			Indicator: creditDebitIndicator(amount),
+			Status:    "BOOK",
+			Booking:   camtDate{DateTime: entryTime(e, account.From).Format(camtDateTimeLayout)},
+			Value:     camtDate{Date: valueDate(e, account.From)},
+			SvcrRef:   e.TranRefNo,
+			TxCode:    camtTxCode{Code: e.DebitOrCredit, Issuer: ofxBankID},
+			Details: camtDetails{Tx: camtTxDetails{
+				Refs: camtRefs{SvcrRef: e.TranRefNo},
+				Info: e.Narration,
+			}},
+		}
+		if entry.TxCode.Code == "" {
+			entry.TxCode.Code = entry.Indicator
+		}
+		stmt.Entries = append(stmt.Entries, entry)
+	}
+
+	doc := camtDocument{
+		Xmlns: camt053Namespace,
+		Stmt: camtBkToCstmrStmt{
+			Header:    camtGroupHeader{MsgID: id, CreDtTm: now.Format(camtDateTimeLayout)},
+			Statement: stmt,
+		},
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// prepareStatementExport fills account defaults and computes balances
+func prepareStatementExport(account StatementAccount, entries []CollectionStatementEntry) (StatementAccount, *StatementSummary) {
+	if account.Currency == "" {
+		account.Currency = defaultStatementCurrency
+	}
+	if account.AccountNo == "" && len(entries) > 0 {
+		account.AccountNo = entries[0].AccountNo
+	}
+	if account.From.IsZero() || account.To.IsZero() {
+		var first, last time.Time
+		for _, e := range entries {
+			t, err := e.TxnTime()
+			if err != nil {
+				continue
+			}
+			if first.IsZero() || t.Before(first) {
+				first = t
+			}
+			if t.After(last) {
+				last = t
+			}
+		}
+		if first.IsZero() {
+			first = time.Now().In(tanzaniaLocation)
+			last = first
+		}
+		if account.From.IsZero() {
+			account.From = startOfDay(first.In(tanzaniaLocation))
+		}
+		if account.To.IsZero() {
+			account.To = startOfDay(last.In(tanzaniaLocation)).Add(24*time.Hour - time.Second)
+		}
+	}
+	return account, SummarizeStatement(entries, SummaryOptions{})
+}
+
+// entryTime returns the transaction time of e, or fallback if unparseable
+func entryTime(e CollectionStatementEntry, fallback time.Time) time.Time {
+	if t, err := e.TxnTime(); err == nil {
+		return t
+	}
+	if t, err := e.ValueTime(); err == nil {
+		return t
+	}
+	return fallback
+}
+
+// valueDate returns the value date of e as YYYY-MM-DD
+func valueDate(e CollectionStatementEntry, fallback time.Time) string {
+	if t, err := e.ValueTime(); err == nil {
+		return t.In(tanzaniaLocation).Format(statementDateFormat)
+	}
+	return entryTime(e, fallback).In(tanzaniaLocation).Format(statementDateFormat)
+}
+
+// entryID returns a stable identifier for an entry
+func entryID(e CollectionStatementEntry, i int) string {
+	if e.TranRefNo != "" {
+		return e.TranRefNo
+	}
+	return strconv.Itoa(i + 1)
+}
+
+func creditDebitIndicator(amount float64) string {
+	if amount < 0 {
+		return "DBIT"
+	}
+	return "CRDT"
+}
+
+func newCamtBalance(code string, amount float64, currency string, date time.Time) camtBalance {
+	return camtBalance{
+		Type:      code,
+		Amount:    camtAmount{Currency: currency, Value: formatAmount(abs(amount))},
+		Indicator: creditDebitIndicator(amount),
+		Date:      camtDate{Date: date.In(tanzaniaLocation).Format(statementDateFormat)},
+	}
+}
+
+func ofxDate(t time.Time) string {
+	t = t.In(tanzaniaLocation)
+	_, offset := t.Zone()
+	return fmt.Sprintf("%s[%+d:EAT]", t.Format(ofxDateLayout), offset/3600)
+}
+
+func formatAmount(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+func formatNullableAmount(n NullableFloat64) string {
+	if n.Value == nil {
+		return ""
+	}
+	return formatAmount(*n.Value)
+}
+
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// OFX 2.2 document structure
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+var ofxOK = ofxStatus{Code: 0, Severity: "INFO"}
+
+type ofxDocument struct {
+	XMLName xml.Name  `xml:"OFX"`
+	SignOn  ofxSignOn `xml:"SIGNONMSGSRSV1>SONRS"`
+	Bank    ofxBank   `xml:"BANKMSGSRSV1"`
+}
+
+type ofxSignOn struct {
+	Status   ofxStatus `xml:"STATUS"`
+	DTServer string    `xml:"DTSERVER"`
+	Language string    `xml:"LANGUAGE"`
+}
+
+type ofxBank struct {
+	TrnRs ofxStmtTrnRs `xml:"STMTTRNRS"`
+}
+
+type ofxStmtTrnRs struct {
+	TrnUID string    `xml:"TRNUID"`
+	Status ofxStatus `xml:"STATUS"`
+	StmtRs ofxStmtRs `xml:"STMTRS"`
+}
+
+type ofxStmtRs struct {
+	CurDef    string      `xml:"CURDEF"`
+	Account   ofxAccount  `xml:"BANKACCTFROM"`
+	TranList  ofxTranList `xml:"BANKTRANLIST"`
+	LedgerBal ofxBalance  `xml:"LEDGERBAL"`
+}
+
+type ofxAccount struct {
+	BankID   string `xml:"BANKID"`
+	AcctID   string `xml:"ACCTID"`
+	AcctType string `xml:"ACCTTYPE"`
+}
+
+type ofxTranList struct {
+	DTStart      string           `xml:"DTSTART"`
+	DTEnd        string           `xml:"DTEND"`
+	Transactions []ofxTransaction `xml:"STMTTRN"`
+}
+
+type ofxTransaction struct {
+	TrnType  string `xml:"TRNTYPE"`
+	DTPosted string `xml:"DTPOSTED"`
+	TrnAmt   string `xml:"TRNAMT"`
+	FITID    string `xml:"FITID"`
+	Name     string `xml:"NAME,omitempty"`
+	Memo     string `xml:"MEMO,omitempty"`
+}
+
+type ofxBalance struct {
+	Amount string `xml:"BALAMT"`
+	AsOf   string `xml:"DTASOF"`
+}
+
+// camt.053.001.02 document structure
+
+type camtDocument struct {
+	XMLName xml.Name          `xml:"Document"`
+	Xmlns   string            `xml:"xmlns,attr"`
+	Stmt    camtBkToCstmrStmt `xml:"BkToCstmrStmt"`
+}
+
+type camtBkToCstmrStmt struct {
+	Header    camtGroupHeader `xml:"GrpHdr"`
+	Statement camtStatement   `xml:"Stmt"`
+}
+
+type camtGroupHeader struct {
+	MsgID   string `xml:"MsgId"`
+	CreDtTm string `xml:"CreDtTm"`
+}
+
+type camtStatement struct {
+	ID       string        `xml:"Id"`
+	CreDtTm  string        `xml:"CreDtTm"`
+	FromTo   camtFromTo    `xml:"FrToDt"`
+	Account  camtAccount   `xml:"Acct"`
+	Balances []camtBalance `xml:"Bal"`
+	Entries  []camtEntry   `xml:"Ntry"`
+}
+
+type camtFromTo struct {
+	From string `xml:"FrDtTm"`
+	To   string `xml:"ToDtTm"`
+}
+
+type camtAccount struct {
+	ID       string `xml:"Id>Othr>Id"`
+	Currency string `xml:"Ccy,omitempty"`
+	Name     string `xml:"Nm,omitempty"`
+}
+
+type camtBalance struct {
+	Type      string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amount    camtAmount `xml:"Amt"`
+	Indicator string     `xml:"CdtDbtInd"`
+	Date      camtDate   `xml:"Dt"`
+}
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt,omitempty"`
+	DateTime string `xml:"DtTm,omitempty"`
+}
+
+type camtEntry struct {
+	Ref       string      `xml:"NtryRef,omitempty"`
+	Amount    camtAmount  `xml:"Amt"`
+	Indicator string      `xml:"CdtDbtInd"`
+	Status    string      `xml:"Sts"`
+	Booking   camtDate    `xml:"BookgDt"`
+	Value     camtDate    `xml:"ValDt"`
+	SvcrRef   string      `xml:"AcctSvcrRef,omitempty"`
+	TxCode    camtTxCode  `xml:"BkTxCd>Prtry"`
+	Details   camtDetails `xml:"NtryDtls"`
+}
+
+type camtTxCode struct {
+	Code   string `xml:"Cd"`
+	Issuer string `xml:"Issr,omitempty"`
+}
+
+type camtDetails struct {
+	Tx camtTxDetails `xml:"TxDtls"`
+}
+
+type camtTxDetails struct {
+	Refs camtRefs `xml:"Refs"`
+	Info string   `xml:"AddtlTxInf,omitempty"`
+}
+
+type camtRefs struct {
+	SvcrRef string `xml:"AcctSvcrRef,omitempty"`
+}
@@ -0,0 +1,151 @@
+package temboplus
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"strconv"
+	"time"
+)
+
+// statementDateFormat is the date format expected by the statement endpoints
+const statementDateFormat = "2006-01-02"
+
+// StatementIterOptions configures statement iteration
+type StatementIterOptions struct {
+	ChunkDays   int    // Days per request; 1 for daily, 7 for weekly. Default: 7
+	Concurrency int    // Maximum concurrent requests. Default: 4
+	WalletID    string // Optional wallet ID passed on every request
+}
+
+// CollectionStatementEntries streams collection statement entries between
+// start and end (inclusive Tanzanian calendar dates). The range is split into chunks fetched
+// concurrently; entries are yielded chunk by chunk in date order, and
+// entries repeated across adjacent chunks are yielded once. Iteration stops
+// at the first error, which is yielded with a zero entry.
+func (c *Client) CollectionStatementEntries(ctx context.Context, start, end time.Time, opts StatementIterOptions) iter.Seq2[CollectionStatementEntry, error] {
+	return statementEntries(ctx, c.GetCollectionStatement, start, end, opts)
+}
+
+// MainStatementEntries streams main statement entries between start and end
+// (inclusive dates). See CollectionStatementEntries.
+func (c *Client) MainStatementEntries(ctx context.Context, start, end time.Time, opts StatementIterOptions) iter.Seq2[CollectionStatementEntry, error] {
+	return statementEntries(ctx, c.GetMainStatement, start, end, opts)
+}
+
+// statementFetcher fetches one statement request
+type statementFetcher func(context.Context, CollectionStatementRequest) ([]CollectionStatementEntry, error)
+
+func statementEntries(ctx context.Context, fetch statementFetcher, start, end time.Time, opts StatementIterOptions) iter.Seq2[CollectionStatementEntry, error] {
+	return func(yield func(CollectionStatementEntry, error) bool) {
+		reqs, err := splitStatementRange(start, end, opts)
+		if err != nil {
+			yield(CollectionStatementEntry{}, err)
+			return
+		}
+		concurrency := opts.Concurrency
+		if concurrency <= 0 {
+			concurrency = 4
+		}
+
+		// Cancel in-flight requests when the consumer stops early
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		type result struct {
+			entries []CollectionStatementEntry
+			err     error
+		}
+		pending := make([]chan result, len(reqs))
+		launched := 0
+		launch := func() {
+			ch := make(chan result, 1)
+			pending[launched] = ch
+			req := reqs[launched]
+			go func() {
+				entries, err := fetch(ctx, req)
+				ch <- result{entries, err}
+			}()
+			launched++
+		}
+		for launched < len(reqs) && launched < concurrency {
+			launch()
+		}
+
+		var previous map[string]struct{}
+		for i, req := range reqs {
+			var res result
+			select {
+			case res = <-pending[i]:
+			case <-ctx.Done():
+				yield(CollectionStatementEntry{}, ctx.Err())
+				return
+			}
+			pending[i] = nil
+			if launched < len(reqs) {
+				launch()
+			}
+			if res.err != nil {
+				yield(CollectionStatementEntry{}, fmt.Errorf("statement %s to %s: %w", req.StartDate, req.EndDate, res.err))
+				return
+			}
+
+			// Overlaps only occur between adjacent chunks, so remembering the
+			// previous chunk keeps memory bounded
+			current := make(map[string]struct{}, len(res.entries))
+			for _, e := range res.entries {
+				key := statementEntryKey(e)
+				if _, dup := previous[key]; dup {
+					continue
+				}
+				if _, dup := current[key]; dup {
+					continue
+				}
+				current[key] = struct{}{}
+				if !yield(e, nil) {
+					return
+				}
+			}
+			previous = current
+		}
+	}
+}
+
+// splitStatementRange splits the Tanzanian calendar days from start to end
+// into consecutive requests of opts.ChunkDays days
+func splitStatementRange(start, end time.Time, opts StatementIterOptions) ([]CollectionStatementRequest, error) {
+	chunkDays := opts.ChunkDays
+	if chunkDays <= 0 {
+		chunkDays = 7
+	}
+	start = startOfDay(start.In(tanzaniaLocation))
+	end = startOfDay(end.In(tanzaniaLocation))
+	if end.Before(start) {
+		return nil, fmt.Errorf("end date %s is before start date %s", end.Format(statementDateFormat), start.Format(statementDateFormat))
+	}
+
+	var reqs []CollectionStatementRequest
+	for from := start; !from.After(end); from = from.AddDate(0, 0, chunkDays) {
+		to := from.AddDate(0, 0, chunkDays-1)
+		if to.After(end) {
+			to = end
+		}
+		reqs = append(reqs, CollectionStatementRequest{
+			StartDate: from.Format(statementDateFormat),
+			EndDate:   to.Format(statementDateFormat),
+			WalletID:  opts.WalletID,
+		})
+	}
+	return reqs, nil
+}
+
+// startOfDay returns midnight of t's day in t's location
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// statementEntryKey identifies an entry for de-duplication
+func statementEntryKey(e CollectionStatementEntry) string {
+	return e.AccountNo + "|" + e.TranRefNo + "|" + e.TxnDate + "|" + e.DebitOrCredit + "|" +
+		strconv.FormatFloat(e.Amount(), 'f', -1, 64) + "|" + strconv.FormatFloat(e.Balance, 'f', -1, 64)
+}
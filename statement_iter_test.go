@@ -0,0 +1,70 @@
+package temboplus_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	temboplus "github.com/techliana/temboplus-golang-sdk"
+	"github.com/techliana/temboplus-golang-sdk/temboplustest"
+)
+
+func statementEntry(day, valueDay int) temboplus.CollectionStatementEntry {
+	amount := float64(day * 1000)
+	e := temboplus.CollectionStatementEntry{
+		AccountNo:     temboplustest.CollectionAccountNo,
+		DebitOrCredit: "Credit",
+		TranRefNo:     fmt.Sprintf("TXN-%02d", day),
+		TxnDate:       fmt.Sprintf("2026-03-%02d 23:30:00", day),
+		ValueDate:     fmt.Sprintf("2026-03-%02d", valueDay),
+		Balance:       amount,
+	}
+	e.AmountCredited.Value = &amount
+	return e
+}
+
+func TestCollectionStatementEntriesAcrossChunks(t *testing.T) {
+	srv := temboplustest.NewServer()
+	defer srv.Close()
+
+	// TXN-03 is returned with both value dates, so it appears at the end of
+	// the 1-3 March chunk and again at the start of the 4-6 March chunk
+	var statement []temboplus.CollectionStatementEntry
+	for day := 1; day <= 21; day++ {
+		statement = append(statement, statementEntry(day, day))
+		if day == 3 {
+			statement = append(statement, statementEntry(3, 4))
+		}
+	}
+	srv.AddWallet(temboplustest.Wallet{AccountNo: temboplustest.CollectionAccountNo, Statement: statement})
+
+	// Early requests answer last so chunks complete out of order
+	srv.Script(temboplus.EndpointWalletCollectionStatement,
+		temboplustest.Delay(90*time.Millisecond),
+		temboplustest.Delay(60*time.Millisecond),
+		temboplustest.Delay(30*time.Millisecond),
+	)
+
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, temboplus.TanzaniaLocation())
+	end := time.Date(2026, 3, 20, 12, 0, 0, 0, temboplus.TanzaniaLocation())
+	var got []string
+	for e, err := range srv.Client().CollectionStatementEntries(context.Background(), start, end, temboplus.StatementIterOptions{ChunkDays: 3, Concurrency: 3}) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, e.TranRefNo)
+	}
+
+	if len(got) != 20 {
+		t.Fatalf("got %d entries, want 20: %v", len(got), got)
+	}
+	for i, ref := range got {
+		if want := fmt.Sprintf("TXN-%02d", i+1); ref != want {
+			t.Errorf("entry %d = %s, want %s", i, ref, want)
+		}
+	}
+	if n := requestCount(srv, temboplus.EndpointWalletCollectionStatement); n != 7 {
+		t.Errorf("%d statement requests, want 7", n)
+	}
+}
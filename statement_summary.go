@@ -0,0 +1,231 @@
+package temboplus
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// NarrationCategory groups statement entries whose narration matches Pattern
+type NarrationCategory struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// DefaultNarrationCategories groups entries by channel using common
+// narration wording. Entries matching none are counted under "Other".
+var DefaultNarrationCategories = []NarrationCategory{
+	{Name: "Charges", Pattern: regexp.MustCompile(`(?i)\b(charges?|fees?|commission|levy)\b`)},
+	{Name: "Tigo", Pattern: regexp.MustCompile(`(?i)tigo|mixx|TZ-TIGO`)},
+	{Name: "Airtel", Pattern: regexp.MustCompile(`(?i)airtel|TZ-AIRTEL`)},
+	{Name: "Halotel", Pattern: regexp.MustCompile(`(?i)halo|TZ-HALOTEL`)},
+	{Name: "Vodacom", Pattern: regexp.MustCompile(`(?i)vodacom|m-?pesa`)},
+	{Name: "Bank", Pattern: regexp.MustCompile(`(?i)\bbank\b|TZ-BANK|\bEFT\b|\bTISS\b`)},
+}
+
+// defaultSummaryTolerance is the default allowed running-balance drift
+const defaultSummaryTolerance = 0.005
+
+// SummaryOptions configures statement summaries
+type SummaryOptions struct {
+	Categories []NarrationCategory // Default: DefaultNarrationCategories
+	Tolerance  float64             // Allowed running-balance drift. Default: 0.005
+}
+
+// CategorySummary aggregates entries in one narration category
+type CategorySummary struct {
+	Count   int
+	Credits float64
+	Debits  float64
+}
+
+// DailySummary aggregates the entries of one day (Tanzanian time)
+type DailySummary struct {
+	Date           string // YYYY-MM-DD
+	Count          int
+	Credits        float64
+	Debits         float64
+	ClosingBalance float64
+}
+
+// BalanceInconsistency flags an entry whose balance does not equal the
+// previous balance plus the entry amount
+type BalanceInconsistency struct {
+	Index    int // Position of the entry in the summarized order
+	Entry    CollectionStatementEntry
+	Expected float64
+	Actual   float64
+}
+
+// Difference returns Actual minus Expected
+func (b BalanceInconsistency) Difference() float64 {
+	return b.Actual - b.Expected
+}
+
+// StatementSummary describes a statement period
+type StatementSummary struct {
+	Entries         int
+	OpeningBalance  float64 // Balance before the first entry
+	ClosingBalance  float64 // Balance after the last entry
+	TotalCredits    float64
+	TotalDebits     float64
+	CreditCount     int
+	DebitCount      int
+	Categories      map[string]CategorySummary
+	Daily           []DailySummary
+	Inconsistencies []BalanceInconsistency
+	Tolerance       float64 // Allowed running-balance drift per entry, from SummaryOptions
+}
+
+// NetChange returns total credits minus total debits
+func (s *StatementSummary) NetChange() float64 {
+	return s.TotalCredits - s.TotalDebits
+}
+
+// Consistent reports whether every running balance checked out and the
+// closing balance equals the opening balance plus the net change, allowing
+// Tolerance of drift per entry
+func (s *StatementSummary) Consistent() bool {
+	tolerance := s.Tolerance
+	if tolerance <= 0 {
+		tolerance = defaultSummaryTolerance
+	}
+	return len(s.Inconsistencies) == 0 &&
+		math.Abs(s.OpeningBalance+s.NetChange()-s.ClosingBalance) <= tolerance*float64(s.Entries+1)
+}
+
+// StatementSummarizer builds a StatementSummary incrementally from entries
+// in chronological order, e.g. from CollectionStatementEntries
+type StatementSummarizer struct {
+	opts    SummaryOptions
+	summary StatementSummary
+	days    map[string]int
+}
+
+// NewStatementSummarizer creates a summarizer
+func NewStatementSummarizer(opts SummaryOptions) *StatementSummarizer {
+	if opts.Categories == nil {
+		opts.Categories = DefaultNarrationCategories
+	}
+	if opts.Tolerance <= 0 {
+		opts.Tolerance = defaultSummaryTolerance
+	}
+	return &StatementSummarizer{
+		opts:    opts,
+		summary: StatementSummary{Categories: make(map[string]CategorySummary), Tolerance: opts.Tolerance},
+		days:    make(map[string]int),
+	}
+}
+
+// Add accounts for the next entry
+func (z *StatementSummarizer) Add(e CollectionStatementEntry) {
+	s := &z.summary
+	amount := e.Amount()
+
+	if s.Entries == 0 {
+		s.OpeningBalance = e.Balance - amount
+	} else if expected := s.ClosingBalance + amount; math.Abs(expected-e.Balance) > z.opts.Tolerance {
+		s.Inconsistencies = append(s.Inconsistencies, BalanceInconsistency{
+			Index:    s.Entries,
+			Entry:    e,
+			Expected: expected,
+			Actual:   e.Balance,
+		})
+	}
+	s.Entries++
+	s.ClosingBalance = e.Balance
+
+	var credit, debit float64
+	if amount >= 0 {
+		credit = amount
+		s.TotalCredits += credit
+		s.CreditCount++
+	} else {
+		debit = -amount
+		s.TotalDebits += debit
+		s.DebitCount++
+	}
+
+	category := z.categorize(e.Narration)
+	c := s.Categories[category]
+	c.Count++
+	c.Credits += credit
+	c.Debits += debit
+	s.Categories[category] = c
+
+	date := e.ValueDate
+	if t, err := e.TxnTime(); err == nil {
+		date = t.In(tanzaniaLocation).Format(statementDateFormat)
+	} else if t, err := e.ValueTime(); err == nil {
+		date = t.In(tanzaniaLocation).Format(statementDateFormat)
+	}
+	i, ok := z.days[date]
+	if !ok {
+		i = len(s.Daily)
+		z.days[date] = i
+		s.Daily = append(s.Daily, DailySummary{Date: date})
+	}
+	d := &s.Daily[i]
+	d.Count++
+	d.Credits += credit
+	d.Debits += debit
+	d.ClosingBalance = e.Balance
+}
+
+// Summary returns the summary of the entries added so far
+func (z *StatementSummarizer) Summary() *StatementSummary {
+	s := z.summary
+	s.Categories = make(map[string]CategorySummary, len(z.summary.Categories))
+	for k, v := range z.summary.Categories {
+		s.Categories[k] = v
+	}
+	s.Daily = append([]DailySummary(nil), z.summary.Daily...)
+	sort.SliceStable(s.Daily, func(a, b int) bool { return s.Daily[a].Date < s.Daily[b].Date })
+	s.Inconsistencies = append([]BalanceInconsistency(nil), z.summary.Inconsistencies...)
+	return &s
+}
+
+func (z *StatementSummarizer) categorize(narration string) string {
+	for _, c := range z.opts.Categories {
+		if c.Pattern != nil && c.Pattern.MatchString(narration) {
+			return c.Name
+		}
+	}
+	return "Other"
+}
+
+// SummarizeStatement summarizes statement entries. When every transaction
+// date parses, entries are put in chronological order first (stable, so
+// same-time entries keep their order); otherwise the given order is used.
+func SummarizeStatement(entries []CollectionStatementEntry, opts SummaryOptions) *StatementSummary {
+	ordered := append([]CollectionStatementEntry(nil), entries...)
+	times := make([]time.Time, len(ordered))
+	sortable := true
+	for i, e := range ordered {
+		t, err := e.TxnTime()
+		if err != nil {
+			sortable = false
+			break
+		}
+		times[i] = t
+	}
+	if sortable {
+		idx := make([]int, len(ordered))
+		for i := range idx {
+			idx[i] = i
+		}
+		sort.SliceStable(idx, func(a, b int) bool { return times[idx[a]].Before(times[idx[b]]) })
+		sorted := make([]CollectionStatementEntry, len(ordered))
+		for i, j := range idx {
+			sorted[i] = ordered[j]
+		}
+		ordered = sorted
+	}
+
+	z := NewStatementSummarizer(opts)
+	for _, e := range ordered {
+		z.Add(e)
+	}
+	return z.Summary()
+}
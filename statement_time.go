@@ -0,0 +1,124 @@
+package temboplus
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxStatementRangeDays is the longest range accepted by NewStatementRequest.
+// Use CollectionStatementEntries or MainStatementEntries for longer periods.
+const MaxStatementRangeDays = 31
+
+var tanzaniaLocation = loadTanzaniaLocation()
+
+// TanzaniaLocation returns the Africa/Dar_es_Salaam time zone used by
+// TemboPlus for statement dates. It falls back to a fixed UTC+3 zone when the
+// system has no time zone database (Tanzania observes no daylight saving).
+func TanzaniaLocation() *time.Location {
+	return tanzaniaLocation
+}
+
+func loadTanzaniaLocation() *time.Location {
+	if loc, err := time.LoadLocation("Africa/Dar_es_Salaam"); err == nil {
+		return loc
+	}
+	return time.FixedZone("EAT", 3*60*60)
+}
+
+// statementTimeLayouts are the accepted formats for txnDate and valueDate,
+// tried in order
+var statementTimeLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04:05.999999999",
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02",
+	"02-01-2006 15:04:05",
+	"02-01-2006",
+	"02/01/2006 15:04:05",
+	"02/01/2006",
+	"02-Jan-2006",
+}
+
+// NewStatementRequest builds a statement request for the calendar days
+// containing start and end in Tanzanian time. It fails if end is before
+// start or the range exceeds MaxStatementRangeDays.
+func NewStatementRequest(start, end time.Time) (CollectionStatementRequest, error) {
+	req := CollectionStatementRequest{
+		StartDate: start.In(tanzaniaLocation).Format(statementDateFormat),
+		EndDate:   end.In(tanzaniaLocation).Format(statementDateFormat),
+	}
+	return req, req.Validate()
+}
+
+// NewWalletStatementRequest is like NewStatementRequest for a specific wallet
+func NewWalletStatementRequest(walletID string, start, end time.Time) (CollectionStatementRequest, error) {
+	req, err := NewStatementRequest(start, end)
+	req.WalletID = walletID
+	return req, err
+}
+
+// Validate checks that the dates are well formed, ordered and within
+// MaxStatementRangeDays
+func (r CollectionStatementRequest) Validate() error {
+	start, end, err := r.Range()
+	if err != nil {
+		return err
+	}
+	if end.Before(start) {
+		return fmt.Errorf("endDate %s is before startDate %s", r.EndDate, r.StartDate)
+	}
+	if days := int(end.Sub(start).Hours()/24) + 1; days > MaxStatementRangeDays {
+		return fmt.Errorf("statement range of %d days exceeds the maximum of %d", days, MaxStatementRangeDays)
+	}
+	return nil
+}
+
+// Range returns the start of StartDate and the start of EndDate in Tanzanian time
+func (r CollectionStatementRequest) Range() (start, end time.Time, err error) {
+	if start, err = time.ParseInLocation(statementDateFormat, r.StartDate, tanzaniaLocation); err != nil {
+		return start, end, fmt.Errorf("invalid startDate %q: expected YYYY-MM-DD", r.StartDate)
+	}
+	if end, err = time.ParseInLocation(statementDateFormat, r.EndDate, tanzaniaLocation); err != nil {
+		return start, end, fmt.Errorf("invalid endDate %q: expected YYYY-MM-DD", r.EndDate)
+	}
+	return start, end, nil
+}
+
+// TxnTime returns TxnDate parsed as a time. Values without a zone are
+// taken as Tanzanian time.
+func (e CollectionStatementEntry) TxnTime() (time.Time, error) {
+	return ParseStatementTime(e.TxnDate)
+}
+
+// ValueTime returns ValueDate parsed as a time. Values without a zone are
+// taken as Tanzanian time.
+func (e CollectionStatementEntry) ValueTime() (time.Time, error) {
+	return ParseStatementTime(e.ValueDate)
+}
+
+// ParseStatementTime parses a date or timestamp as returned in statements.
+// It accepts the common date-time layouts as well as Unix epoch seconds or
+// milliseconds; values without a zone are taken as Tanzanian time.
+func ParseStatementTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, fmt.Errorf("empty statement date")
+	}
+	for _, layout := range statementTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, tanzaniaLocation); err == nil {
+			return t, nil
+		}
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil && n > 0 {
+		if n > 1e11 {
+			return time.UnixMilli(n).In(tanzaniaLocation), nil
+		}
+		return time.Unix(n, 0).In(tanzaniaLocation), nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognized statement date %q", s)
+}
@@ -0,0 +1,148 @@
+package temboplus
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// TransactionStatus is the status of a collection or payout. The Status
+// constants are untyped, so they compare and assign to both
+// TransactionStatus and string.
+type TransactionStatus string
+
+// ParseTransactionStatus normalizes a status code. Unknown codes are
+// returned unchanged together with an error, so they can still be stored.
+func ParseTransactionStatus(s string) (TransactionStatus, error) {
+	status := TransactionStatus(strings.ToUpper(strings.TrimSpace(s)))
+	if !status.IsKnown() {
+		return status, fmt.Errorf("unknown transaction status: %q", s)
+	}
+	return status, nil
+}
+
+// String returns the status code
+func (s TransactionStatus) String() string {
+	return string(s)
+}
+
+// IsKnown reports whether s is one of the documented status codes
+func (s TransactionStatus) IsKnown() bool {
+	switch s {
+	case StatusPendingACK, StatusPaymentAccepted, StatusPaymentRejected, StatusGenericError:
+		return true
+	}
+	return false
+}
+
+// IsPending reports whether the transaction is awaiting a final outcome
+func (s TransactionStatus) IsPending() bool {
+	return s == StatusPendingACK
+}
+
+// IsTerminal reports whether the status is final
+func (s TransactionStatus) IsTerminal() bool {
+	return s == StatusPaymentAccepted || s == StatusPaymentRejected || s == StatusGenericError
+}
+
+// IsSuccess reports whether the payment was accepted
+func (s TransactionStatus) IsSuccess() bool {
+	return s == StatusPaymentAccepted
+}
+
+// IsFailure reports whether the payment was rejected or failed
+func (s TransactionStatus) IsFailure() bool {
+	return s == StatusPaymentRejected || s == StatusGenericError
+}
+
+// CanTransition reports whether a transaction may move from one status to
+// another. The empty status stands for a transaction not seen yet. Repeating
+// the current status is allowed so duplicate webhooks are harmless; leaving
+// a terminal status is not.
+func CanTransition(from, to TransactionStatus) bool {
+	if !to.IsKnown() {
+		return false
+	}
+	switch {
+	case from == "":
+		return true
+	case from == to:
+		return true
+	case from.IsPending():
+		return to.IsTerminal()
+	}
+	return false
+}
+
+// TransitionError reports an illegal status transition
+type TransitionError struct {
+	TransactionRef string
+	From           TransactionStatus
+	To             TransactionStatus
+}
+
+func (e *TransitionError) Error() string {
+	switch {
+	case !e.To.IsKnown():
+		return fmt.Sprintf("transaction %s: unknown status %q", e.TransactionRef, e.To)
+	case e.From.IsTerminal() && e.To.IsPending():
+		return fmt.Sprintf("transaction %s: out-of-order status %s after %s", e.TransactionRef, e.To, e.From)
+	default:
+		return fmt.Sprintf("transaction %s: illegal transition from %s to %s", e.TransactionRef, e.From, e.To)
+	}
+}
+
+// StatusMachine tracks the status of transactions by reference and rejects
+// illegal transitions, e.g. a PAYMENT_REJECTED webhook for a payment already
+// accepted. It is safe for concurrent use.
+type StatusMachine struct {
+	mu       sync.Mutex
+	statuses map[string]TransactionStatus
+}
+
+// NewStatusMachine returns an empty StatusMachine
+func NewStatusMachine() *StatusMachine {
+	return &StatusMachine{statuses: make(map[string]TransactionStatus)}
+}
+
+// Status returns the current status of a transaction
+func (m *StatusMachine) Status(transactionRef string) (TransactionStatus, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.statuses[transactionRef]
+	return s, ok
+}
+
+// Apply moves a transaction to a new status. It reports whether the status
+// changed; a repeated status returns false and no error. Illegal
+// transitions return a *TransitionError and leave the status unchanged.
+func (m *StatusMachine) Apply(transactionRef string, to TransactionStatus) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	from := m.statuses[transactionRef]
+	if !CanTransition(from, to) {
+		return false, &TransitionError{TransactionRef: transactionRef, From: from, To: to}
+	}
+	if from == to {
+		return false, nil
+	}
+	m.statuses[transactionRef] = to
+	return true, nil
+}
+
+// ApplyResponse applies the status of an API response
+func (m *StatusMachine) ApplyResponse(resp *MobileMoneyCollectionResponse) (bool, error) {
+	return m.Apply(resp.TransactionRef, resp.StatusCode)
+}
+
+// ApplyWebhook applies the status of a webhook
+func (m *StatusMachine) ApplyWebhook(payload *WebhookPayload) (bool, error) {
+	return m.Apply(payload.TransactionRef, payload.StatusCode)
+}
+
+// Forget stops tracking a transaction
+func (m *StatusMachine) Forget(transactionRef string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.statuses, transactionRef)
+}
@@ -0,0 +1,153 @@
+package temboplus_test
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	temboplus "github.com/techliana/temboplus-golang-sdk"
+	"github.com/techliana/temboplus-golang-sdk/temboplustest"
+)
+
+const (
+	pending  = temboplus.TransactionStatus(temboplus.StatusPendingACK)
+	accepted = temboplus.TransactionStatus(temboplus.StatusPaymentAccepted)
+	rejected = temboplus.TransactionStatus(temboplus.StatusPaymentRejected)
+	failed   = temboplus.TransactionStatus(temboplus.StatusGenericError)
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to temboplus.TransactionStatus
+		want     bool
+	}{
+		{"", pending, true},
+		{"", accepted, true},
+		{"", "SETTLED", false},
+		{pending, pending, true},
+		{pending, accepted, true},
+		{pending, rejected, true},
+		{pending, failed, true},
+		{pending, "", false},
+		{accepted, accepted, true},
+		{accepted, pending, false},
+		{accepted, rejected, false},
+		{rejected, accepted, false},
+		{failed, accepted, false},
+		{failed, pending, false},
+	}
+	for _, tt := range tests {
+		if got := temboplus.CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestStatusMachineApply(t *testing.T) {
+	type step struct {
+		to          temboplus.TransactionStatus
+		wantChanged bool
+		wantErr     bool
+	}
+	tests := []struct {
+		name  string
+		steps []step
+		want  temboplus.TransactionStatus
+	}{
+		{
+			name:  "pending then accepted",
+			steps: []step{{pending, true, false}, {accepted, true, false}},
+			want:  accepted,
+		},
+		{
+			name:  "duplicate webhook is ignored",
+			steps: []step{{pending, true, false}, {accepted, true, false}, {accepted, false, false}},
+			want:  accepted,
+		},
+		{
+			name:  "late pending response after the webhook",
+			steps: []step{{accepted, true, false}, {pending, false, true}},
+			want:  accepted,
+		},
+		{
+			name:  "conflicting final status",
+			steps: []step{{pending, true, false}, {rejected, true, false}, {accepted, false, true}},
+			want:  rejected,
+		},
+		{
+			name:  "unknown status",
+			steps: []step{{pending, true, false}, {"SETTLED", false, true}},
+			want:  pending,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := temboplus.NewStatusMachine()
+			for i, st := range tt.steps {
+				changed, err := m.Apply("REF-1", st.to)
+				if changed != st.wantChanged {
+					t.Errorf("step %d: changed = %v, want %v", i+1, changed, st.wantChanged)
+				}
+				var terr *temboplus.TransitionError
+				if st.wantErr != errors.As(err, &terr) {
+					t.Errorf("step %d: error = %v, want TransitionError %v", i+1, err, st.wantErr)
+				}
+			}
+			if got, _ := m.Status("REF-1"); got != tt.want {
+				t.Errorf("status = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusMachineWithServer(t *testing.T) {
+	m := temboplus.NewStatusMachine()
+	srv := temboplustest.NewServer()
+	defer srv.Close()
+	srv.AutoWebhook = false
+	client := srv.Client()
+
+	hooks := httptest.NewServer(client.WebhookHandler(func(ctx context.Context, webhook *temboplus.WebhookPayload) error {
+		_, err := m.ApplyWebhook(webhook)
+		return err
+	}))
+	defer hooks.Close()
+	srv.CallbackURL = hooks.URL
+
+	resp, err := client.CollectFromMobileMoney(context.Background(), temboplus.MobileMoneyCollectionRequest{
+		MSISDN:          "255715123456",
+		Channel:         temboplus.ChannelAuto,
+		Amount:          5000,
+		Narration:       "Order 1",
+		TransactionRef:  "ORDER-1",
+		TransactionDate: temboplus.FormatTransactionDate(time.Now()),
+		CallbackURL:     hooks.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := m.ApplyResponse(resp); !changed || err != nil {
+		t.Fatalf("ApplyResponse = %v, %v; want changed", changed, err)
+	}
+
+	if err := srv.FireWebhook("ORDER-1", temboplus.StatusPaymentAccepted); err != nil {
+		t.Fatalf("accepted webhook: %v", err)
+	}
+	if err := srv.FireWebhook("ORDER-1", temboplus.StatusPaymentAccepted); err != nil {
+		t.Fatalf("duplicate webhook: %v", err)
+	}
+	if err := srv.FireWebhook("ORDER-1", temboplus.StatusPaymentRejected); err == nil {
+		t.Fatal("conflicting webhook was accepted")
+	}
+	if got, _ := m.Status("ORDER-1"); got != accepted {
+		t.Errorf("status = %s, want %s", got, accepted)
+	}
+
+	// The collection response arriving late must not move it back
+	var terr *temboplus.TransitionError
+	if _, err := m.ApplyResponse(resp); !errors.As(err, &terr) {
+		t.Errorf("late %s response: error = %v, want TransitionError", resp.StatusCode, err)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -16,6 +18,14 @@ type Client struct {
 	accountID  string
 	secretKey  string
 	httpClient *http.Client
+	preflight  *balanceCache
+	cache      *responseCache
+	registry   *Registry
+
+	channelOverrides map[string]string
+	collections      CollectionStore
+	collectionsError func(ctx context.Context, rec CollectionRecord, err error)
+	refundMu         sync.Mutex
 }
 
 // ClientConfig holds configuration for the TemboPlus client
@@ -24,6 +34,40 @@ type ClientConfig struct {
 	AccountID  string        // Your account ID (x-account-id)
 	SecretKey  string        // Your secret key (x-secret-key)
 	Timeout    time.Duration // Default: 30 seconds
+	BaseURL    string        // Optional: overrides the environment base URL (e.g. a test server)
+
+	// Optional: record interactions to CassettePath or replay them from it.
+	// An unknown mode or empty path makes every request fail without
+	// reaching the network.
+	CassetteMode CassetteMode
+	CassettePath string
+
+	// Optional: check the source wallet balance before submitting payouts and
+	// fail with InsufficientFundsError instead of sending the request.
+	// Balances are reused for BalancePreflightTTL (default: 15 seconds).
+	BalancePreflight    bool
+	BalancePreflightTTL time.Duration
+
+	// Optional: cache balance and wallet list responses for CacheTTL.
+	// Concurrent identical requests share one call; payouts and collections
+	// invalidate the affected balances. Zero disables caching.
+	CacheTTL time.Duration
+
+	// Optional: countries, currencies and channels accepted by the
+	// validators. Default: DefaultRegistry()
+	Registry *Registry
+
+	// Optional: collection channels for ported numbers, keyed by MSISDN in
+	// any accepted format. Consulted when a collection uses ChannelAuto.
+	ChannelOverrides map[string]string
+
+	// Optional: remember collections so they can be refunded with Refund
+	CollectionStore CollectionStore
+
+	// Optional: called when an accepted collection cannot be saved in
+	// CollectionStore. The collection was still submitted, so
+	// CollectFromMobileMoney does not fail. Default: log with log.Default()
+	CollectionStoreError func(ctx context.Context, rec CollectionRecord, err error)
 }
 type Environment string
 
@@ -38,19 +82,41 @@ func NewClient(config ClientConfig) *Client {
 	if config.Environmen == Production {
 		baseUrl = DefaultBaseURLProduction
 	}
+	if config.BaseURL != "" {
+		baseUrl = strings.TrimRight(config.BaseURL, "/")
+	}
 
 	if config.Timeout == 0 {
 		config.Timeout = 30 * time.Second
 	}
 
-	return &Client{
-		baseURL:   baseUrl,
-		accountID: config.AccountID,
-		secretKey: config.SecretKey,
-		httpClient: &http.Client{
-			Timeout: config.Timeout,
-		},
+	httpClient := &http.Client{
+		Timeout: config.Timeout,
+	}
+	if config.CassetteMode != CassetteOff {
+		httpClient.Transport = newCassetteTransport(config.CassetteMode, config.CassettePath, nil)
+	}
+
+	client := &Client{
+		baseURL:    baseUrl,
+		accountID:  config.AccountID,
+		secretKey:  config.SecretKey,
+		httpClient: httpClient,
+		registry:   config.Registry,
+	}
+	if client.registry == nil {
+		client.registry = DefaultRegistry()
+	}
+	client.channelOverrides = client.newChannelOverrides(config.ChannelOverrides)
+	client.collections = config.CollectionStore
+	client.collectionsError = config.CollectionStoreError
+	if config.CacheTTL > 0 {
+		client.cache = newResponseCache(config.CacheTTL, config.Timeout)
+	}
+	if config.BalancePreflight {
+		client.preflight = newBalanceCache(config.BalancePreflightTTL)
 	}
+	return client
 }
 
 func (e Error) Error() string {
@@ -90,13 +156,13 @@ func (c *Client) makeRequest(ctx context.Context, method, endpoint string, paylo
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, &RequestError{Err: fmt.Errorf("request failed: %w", err)}
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, &RequestError{Err: fmt.Errorf("failed to read response: %w", err)}
 	}
 
 	// If HTTP status is not OK, try to unmarshal API error wrapper
@@ -105,18 +171,18 @@ func (c *Client) makeRequest(ctx context.Context, method, endpoint string, paylo
 		if err := json.Unmarshal(respBody, &apiErr); err == nil && apiErr.StatusCode != 0 {
 			return nil, apiErr
 		}
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(respBody))
+		return nil, &RequestError{Err: fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(respBody))}
 	}
 
 	var response MobileMoneyCollectionResponse
 	if err := json.Unmarshal(respBody, &response); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+		return nil, &RequestError{Err: fmt.Errorf("failed to unmarshal response: %w", err)}
 	}
 
 	// Check for error status codes
-	if response.StatusCode == StatusPaymentRejected || response.StatusCode == StatusGenericError {
+	if response.StatusCode.IsFailure() {
 		return &response, Error{
-			StatusCode: response.StatusCode,
+			StatusCode: string(response.StatusCode),
 			Message:    "Request failed",
 		}
 	}
@@ -124,17 +190,33 @@ func (c *Client) makeRequest(ctx context.Context, method, endpoint string, paylo
 	return &response, nil
 }
 
-// CollectFromMobileMoney sends a USSD push request to collect money from a mobile subscriber
+// CollectFromMobileMoney sends a USSD push request to collect money from a mobile subscriber.
+// With Channel set to ChannelAuto the channel is chosen by ResolveChannel and
+// the MSISDN normalized; the response reports the channel used.
 func (c *Client) CollectFromMobileMoney(ctx context.Context, req MobileMoneyCollectionRequest) (*MobileMoneyCollectionResponse, error) {
+	if strings.EqualFold(req.Channel, ChannelAuto) {
+		p, channel, err := c.ResolveChannel(req.MSISDN)
+		if err != nil {
+			return nil, err
+		}
+		req.MSISDN = p.MSISDN
+		req.Channel = channel
+	}
+
 	// Validate required fields
 	if err := c.validateMobileMoneyRequest(req); err != nil {
 		return nil, err
 	}
 
 	response, err := c.makeRequest(ctx, http.MethodPost, EndpointCollection, req)
+	if response != nil {
+		response.Channel = req.Channel
+		c.invalidateAfterCollection()
+	}
 	if err != nil {
 		return response, err
 	}
+	c.recordCollection(ctx, req, response)
 
 	return response, nil
 }
@@ -163,12 +245,8 @@ func (c *Client) validateMobileMoneyRequest(req MobileMoneyCollectionRequest) er
 		return fmt.Errorf("callbackUrl is required")
 	}
 
-	// Validate channel
-	if !isValidChannel(req.Channel) {
-		return fmt.Errorf("invalid channel: %s. Supported channels: %v", req.Channel, GetSupportedChannels())
-	}
-
-	return nil
+	// Validate channel, MSISDN and amount against the registry
+	return c.registry.validateCollection(req)
 }
 
 // ValidateWebhook validates and parses an incoming webhook payload
@@ -188,6 +266,10 @@ func (c *Client) ValidateWebhook(payload []byte) (*WebhookPayload, error) {
 
 // GetCollectionBalance retrieves the balance of the collection account
 func (c *Client) GetCollectionBalance(ctx context.Context) (*CollectionBalanceResponse, error) {
+	return c.cachedBalance(ctx, cacheKeyCollectionBalance, c.getCollectionBalance)
+}
+
+func (c *Client) getCollectionBalance(ctx context.Context) (*CollectionBalanceResponse, error) {
 	url := c.baseURL + EndpointWalletCollectionBalance
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
@@ -230,6 +312,10 @@ func (c *Client) GetCollectionBalance(ctx context.Context) (*CollectionBalanceRe
 
 // GetMainBalance retrieves the balance of the main account
 func (c *Client) GetMainBalance(ctx context.Context) (*CollectionBalanceResponse, error) {
+	return c.cachedBalance(ctx, cacheKeyMainBalance, c.getMainBalance)
+}
+
+func (c *Client) getMainBalance(ctx context.Context) (*CollectionBalanceResponse, error) {
 	url := c.baseURL + EndpointWalletMainBalance
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
@@ -378,7 +464,8 @@ func (c *Client) GetPaymentStatus(ctx context.Context, req PaymentStatusRequest)
 	return c.makeRequest(ctx, http.MethodPost, EndpointPaymentStatus, req)
 }
 
-// Constants for status codes
+// Constants for status codes. They are untyped for compatibility; see
+// TransactionStatus.
 const (
 	StatusPendingACK      = "PENDING_ACK"
 	StatusPaymentAccepted = "PAYMENT_ACCEPTED"
@@ -398,7 +485,8 @@ const (
 
 // Helper functions
 
-// GetSupportedChannels returns a list of supported MNO channels
+// GetSupportedChannels returns the built-in Tanzanian MNO channels. See
+// Registry.Channels for all registered channels.
 func GetSupportedChannels() []string {
 	return []string{
 		ChannelTZTigoC2B,
@@ -407,18 +495,8 @@ func GetSupportedChannels() []string {
 	}
 }
 
-// isValidChannel checks if the provided channel is supported
-func isValidChannel(channel string) bool {
-	supportedChannels := GetSupportedChannels()
-	for _, c := range supportedChannels {
-		if c == channel {
-			return true
-		}
-	}
-	return false
-}
-
-// GetSupportedServices returns supported wallet-to-mobile service codes
+// GetSupportedServices returns the built-in Tanzanian wallet-to-mobile
+// service codes. See Registry.Channels for all registered services.
 func GetSupportedServices() []string {
 	return []string{
 		ServiceTZTigoB2C,
@@ -427,17 +505,8 @@ func GetSupportedServices() []string {
 	}
 }
 
-// isValidService checks if provided service code is supported
-func isValidService(service string) bool {
-	for _, s := range GetSupportedServices() {
-		if s == service {
-			return true
-		}
-	}
-	return false
-}
-
-// FormatMSISDN formats a phone number to the required MSISDN format (255XXX123456)
+// FormatMSISDN formats a phone number to the required MSISDN format (255XXX123456).
+// Use ParseMSISDN for full normalization, validation and operator detection.
 func FormatMSISDN(phoneNumber string) string {
 	// Remove any leading + or 0
 	if len(phoneNumber) > 0 && phoneNumber[0] == '+' {
@@ -468,24 +537,21 @@ func FormatTransactionDate(t time.Time) string {
 
 // IsSuccessfulWebhook checks if a webhook indicates a successful payment
 func IsSuccessfulWebhook(webhook *WebhookPayload) bool {
-	return webhook.StatusCode == StatusPaymentAccepted
+	return webhook.StatusCode.IsSuccess()
 }
 
 // IsFailedWebhook checks if a webhook indicates a failed payment
 func IsFailedWebhook(webhook *WebhookPayload) bool {
-	return webhook.StatusCode == StatusPaymentRejected || webhook.StatusCode == StatusGenericError
+	return webhook.StatusCode.IsFailure()
 }
 
-// GetChannelProvider extracts the provider name from a channel
+// GetChannelProvider returns the operator name behind a channel or service
+// code, e.g. Halotel for TZ-HALOTEL-C2B, or "Unknown"
 func GetChannelProvider(channel string) string {
-	switch channel {
-	case ChannelTZTigoC2B:
-		return "Tigo"
-	case ChannelTZAirtelC2B:
-		return "Airtel"
-	default:
-		return "Unknown"
+	if c, ok := DefaultRegistry().Channel(channel); ok && c.Operator != "" {
+		return c.Operator
 	}
+	return "Unknown"
 }
 
 // ValidateMSISDN performs basic validation on MSISDN format
@@ -519,6 +585,19 @@ func BuildCollectionRequest(phoneNumber string, channel string, amount float64,
 
 // ListWallets retrieves all wallets associated with the account
 func (c *Client) ListWallets(ctx context.Context) ([]Wallet, error) {
+	if c.cache == nil {
+		return c.listWallets(ctx)
+	}
+	v, err := c.cache.do(ctx, cacheKeyWalletList, func(ctx context.Context) (interface{}, error) {
+		return c.listWallets(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]Wallet(nil), v.([]Wallet)...), nil
+}
+
+func (c *Client) listWallets(ctx context.Context) ([]Wallet, error) {
 	url := c.baseURL + EndpointWalletList
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
@@ -561,6 +640,12 @@ func (c *Client) ListWallets(ctx context.Context) ([]Wallet, error) {
 
 // GetWalletBalance retrieves the balance of a specific wallet by account number
 func (c *Client) GetWalletBalance(ctx context.Context, accountNo string) (*CollectionBalanceResponse, error) {
+	return c.cachedBalance(ctx, cacheKeyWalletPrefix+accountNo, func(ctx context.Context) (*CollectionBalanceResponse, error) {
+		return c.getWalletBalance(ctx, accountNo)
+	})
+}
+
+func (c *Client) getWalletBalance(ctx context.Context, accountNo string) (*CollectionBalanceResponse, error) {
 	url := c.baseURL + EndpointWalletBalance
 
 	reqBody := WalletBalanceRequest{
@@ -610,14 +695,15 @@ func (c *Client) GetWalletBalance(ctx context.Context, accountNo string) (*Colle
 	return &balance, nil
 }
 
-// PayWalletToBank is a convenience wrapper for bank payouts (TZ-BANK-B2C)
-// Note: The API uses the same endpoint as wallet-to-mobile; msisdn should be in the format <BIC>:<ACCOUNT NUMBER>
+// PayWalletToBank is a convenience wrapper for bank payouts (TZ-BANK-B2C or another registered bank service)
+// Note: The API uses the same endpoint as wallet-to-mobile; msisdn should be in the format <BIC>:<ACCOUNT NUMBER>.
+// PayToBankAccount builds and validates that format from a BankPayoutRequest.
 func (c *Client) PayWalletToBank(ctx context.Context, req WalletToMobileRequest) (*MobileMoneyCollectionResponse, error) {
 	if req.ServiceCode == "" {
 		req.ServiceCode = ServiceTZBankB2C
 	}
-	if req.ServiceCode != ServiceTZBankB2C {
-		return nil, fmt.Errorf("serviceCode must be %s for bank payouts", ServiceTZBankB2C)
+	if service, ok := c.registry.Channel(req.ServiceCode); !ok || !service.Bank {
+		return nil, fmt.Errorf("serviceCode must be a bank service such as %s for bank payouts", ServiceTZBankB2C)
 	}
 	return c.PayWalletToMobile(ctx, req)
 }
@@ -629,26 +715,43 @@ func (c *Client) PayWalletToMobile(ctx context.Context, req WalletToMobileReques
 		return nil, err
 	}
 
+	if err := c.checkSufficientFunds(ctx, req.AccountNo, req.Amount); err != nil {
+		return nil, err
+	}
+
+	return c.submitWalletToMobile(ctx, req)
+}
+
+// submitWalletToMobile sends a validated wallet-to-mobile payout
+func (c *Client) submitWalletToMobile(ctx context.Context, req WalletToMobileRequest) (*MobileMoneyCollectionResponse, error) {
 	// Reuse the common request helper; response shape matches MobileMoneyCollectionResponse
-	return c.makeRequest(ctx, http.MethodPost, EndpointPaymentWalletToMobile, req)
+	response, err := c.makeRequest(ctx, http.MethodPost, EndpointPaymentWalletToMobile, req)
+	c.afterPayout(req.AccountNo, req.Amount, response)
+	return response, err
+}
+
+// afterPayout updates cached balances once a payout from accountNo has been
+// answered
+func (c *Client) afterPayout(accountNo string, amount float64, response *MobileMoneyCollectionResponse) {
+	if response == nil {
+		return
+	}
+	c.InvalidateBalance(accountNo)
+	if c.preflight != nil && response.StatusCode == StatusPendingACK {
+		c.preflight.debit(accountNo, amount)
+	}
 }
 
 func (c *Client) validateWalletToMobileRequest(req WalletToMobileRequest) error {
 	if req.CountryCode == "" {
 		return fmt.Errorf("countryCode is required")
 	}
-	if req.CountryCode != "TZ" {
-		return fmt.Errorf("unsupported countryCode: %s", req.CountryCode)
-	}
 	if req.AccountNo == "" {
 		return fmt.Errorf("accountNo is required")
 	}
 	if req.ServiceCode == "" {
 		return fmt.Errorf("serviceCode is required")
 	}
-	if !isValidService(req.ServiceCode) {
-		return fmt.Errorf("invalid serviceCode: %s. Supported services: %v", req.ServiceCode, GetSupportedServices())
-	}
 	if req.Amount <= 0 {
 		return fmt.Errorf("amount must be greater than 0")
 	}
@@ -661,9 +764,6 @@ func (c *Client) validateWalletToMobileRequest(req WalletToMobileRequest) error
 	if req.CurrencyCode == "" {
 		return fmt.Errorf("currencyCode is required")
 	}
-	if req.CurrencyCode != "TZS" {
-		return fmt.Errorf("unsupported currencyCode: %s", req.CurrencyCode)
-	}
 	if req.RecipientNames == "" {
 		return fmt.Errorf("recipientNames is required")
 	}
@@ -676,5 +776,7 @@ func (c *Client) validateWalletToMobileRequest(req WalletToMobileRequest) error
 	if req.CallbackURL == "" {
 		return fmt.Errorf("callbackUrl is required")
 	}
-	return nil
+
+	// Validate country, currency, service code and destination against the registry
+	return c.registry.validatePayout(req)
 }
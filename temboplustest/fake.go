@@ -0,0 +1,228 @@
+package temboplustest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	temboplus "github.com/techliana/temboplus-golang-sdk"
+)
+
+// Call is a method invocation recorded by FakeClient
+type Call struct {
+	Method string
+	Args   []interface{} // Arguments after ctx, in declaration order
+}
+
+// FakeClient is an in-memory implementation of temboplus.API for unit tests.
+// Every call is recorded. Set the corresponding ...Func field to program a
+// response; when it is nil a plausible default is returned.
+type FakeClient struct {
+	CollectFromMobileMoneyFunc func(ctx context.Context, req temboplus.MobileMoneyCollectionRequest) (*temboplus.MobileMoneyCollectionResponse, error)
+	GetCollectionStatusFunc    func(ctx context.Context, req temboplus.PaymentStatusRequest) (*temboplus.MobileMoneyCollectionResponse, error)
+	PayWalletToMobileFunc      func(ctx context.Context, req temboplus.WalletToMobileRequest) (*temboplus.MobileMoneyCollectionResponse, error)
+	PayWalletToBankFunc        func(ctx context.Context, req temboplus.WalletToMobileRequest) (*temboplus.MobileMoneyCollectionResponse, error)
+	PayToBankAccountFunc       func(ctx context.Context, req temboplus.BankPayoutRequest) (*temboplus.MobileMoneyCollectionResponse, error)
+	GetPaymentStatusFunc       func(ctx context.Context, req temboplus.PaymentStatusRequest) (*temboplus.MobileMoneyCollectionResponse, error)
+	RefundFunc                 func(ctx context.Context, req temboplus.RefundRequest) (*temboplus.MobileMoneyCollectionResponse, error)
+	GetCollectionBalanceFunc   func(ctx context.Context) (*temboplus.CollectionBalanceResponse, error)
+	GetMainBalanceFunc         func(ctx context.Context) (*temboplus.CollectionBalanceResponse, error)
+	GetWalletBalanceFunc       func(ctx context.Context, accountNo string) (*temboplus.CollectionBalanceResponse, error)
+	GetCollectionStatementFunc func(ctx context.Context, req temboplus.CollectionStatementRequest) ([]temboplus.CollectionStatementEntry, error)
+	GetMainStatementFunc       func(ctx context.Context, req temboplus.CollectionStatementRequest) ([]temboplus.CollectionStatementEntry, error)
+	ListWalletsFunc            func(ctx context.Context) ([]temboplus.Wallet, error)
+	ValidateWebhookFunc        func(payload []byte) (*temboplus.WebhookPayload, error)
+
+	mu    sync.Mutex
+	calls []Call
+	seq   int
+}
+
+// Ensure FakeClient implements temboplus.API
+var _ temboplus.API = (*FakeClient)(nil)
+
+// Calls returns every recorded call in order
+func (f *FakeClient) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	out := make([]Call, len(f.calls))
+	copy(out, f.calls)
+	return out
+}
+
+// CallsTo returns the recorded calls to the named method
+func (f *FakeClient) CallsTo(method string) []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []Call
+	for _, c := range f.calls {
+		if c.Method == method {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Reset clears the recorded calls
+func (f *FakeClient) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = nil
+}
+
+func (f *FakeClient) record(method string, args ...interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, Call{Method: method, Args: args})
+}
+
+func (f *FakeClient) pending(ref string) *temboplus.MobileMoneyCollectionResponse {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seq++
+	return &temboplus.MobileMoneyCollectionResponse{
+		StatusCode:     temboplus.StatusPendingACK,
+		TransactionRef: ref,
+		TransactionID:  fmt.Sprintf("FAKE%08d", f.seq),
+	}
+}
+
+// CollectFromMobileMoney records the call and returns PENDING_ACK by default
+func (f *FakeClient) CollectFromMobileMoney(ctx context.Context, req temboplus.MobileMoneyCollectionRequest) (*temboplus.MobileMoneyCollectionResponse, error) {
+	f.record("CollectFromMobileMoney", req)
+	if f.CollectFromMobileMoneyFunc != nil {
+		return f.CollectFromMobileMoneyFunc(ctx, req)
+	}
+	resp := f.pending(req.TransactionRef)
+	resp.Channel = req.Channel
+	return resp, nil
+}
+
+// GetCollectionStatus records the call and returns PAYMENT_ACCEPTED by default
+func (f *FakeClient) GetCollectionStatus(ctx context.Context, req temboplus.PaymentStatusRequest) (*temboplus.MobileMoneyCollectionResponse, error) {
+	f.record("GetCollectionStatus", req)
+	if f.GetCollectionStatusFunc != nil {
+		return f.GetCollectionStatusFunc(ctx, req)
+	}
+	return accepted(req), nil
+}
+
+// PayWalletToMobile records the call and returns PENDING_ACK by default
+func (f *FakeClient) PayWalletToMobile(ctx context.Context, req temboplus.WalletToMobileRequest) (*temboplus.MobileMoneyCollectionResponse, error) {
+	f.record("PayWalletToMobile", req)
+	if f.PayWalletToMobileFunc != nil {
+		return f.PayWalletToMobileFunc(ctx, req)
+	}
+	return f.pending(req.TransactionRef), nil
+}
+
+// PayWalletToBank records the call and returns PENDING_ACK by default
+func (f *FakeClient) PayWalletToBank(ctx context.Context, req temboplus.WalletToMobileRequest) (*temboplus.MobileMoneyCollectionResponse, error) {
+	f.record("PayWalletToBank", req)
+	if f.PayWalletToBankFunc != nil {
+		return f.PayWalletToBankFunc(ctx, req)
+	}
+	return f.pending(req.TransactionRef), nil
+}
+
+// PayToBankAccount records the call and returns PENDING_ACK by default
+func (f *FakeClient) PayToBankAccount(ctx context.Context, req temboplus.BankPayoutRequest) (*temboplus.MobileMoneyCollectionResponse, error) {
+	f.record("PayToBankAccount", req)
+	if f.PayToBankAccountFunc != nil {
+		return f.PayToBankAccountFunc(ctx, req)
+	}
+	return f.pending(req.TransactionRef), nil
+}
+
+// GetPaymentStatus records the call and returns PAYMENT_ACCEPTED by default
+func (f *FakeClient) GetPaymentStatus(ctx context.Context, req temboplus.PaymentStatusRequest) (*temboplus.MobileMoneyCollectionResponse, error) {
+	f.record("GetPaymentStatus", req)
+	if f.GetPaymentStatusFunc != nil {
+		return f.GetPaymentStatusFunc(ctx, req)
+	}
+	return accepted(req), nil
+}
+
+// Refund records the call and returns PENDING_ACK by default
+func (f *FakeClient) Refund(ctx context.Context, req temboplus.RefundRequest) (*temboplus.MobileMoneyCollectionResponse, error) {
+	f.record("Refund", req)
+	if f.RefundFunc != nil {
+		return f.RefundFunc(ctx, req)
+	}
+	ref := req.TransactionRef
+	if ref == "" {
+		ref = req.OriginalTransactionRef + "-R1"
+	}
+	return f.pending(ref), nil
+}
+
+// GetCollectionBalance records the call and returns a zero balance by default
+func (f *FakeClient) GetCollectionBalance(ctx context.Context) (*temboplus.CollectionBalanceResponse, error) {
+	f.record("GetCollectionBalance")
+	if f.GetCollectionBalanceFunc != nil {
+		return f.GetCollectionBalanceFunc(ctx)
+	}
+	return &temboplus.CollectionBalanceResponse{AccountNo: CollectionAccountNo, AccountStatus: "ACTIVE"}, nil
+}
+
+// GetMainBalance records the call and returns a zero balance by default
+func (f *FakeClient) GetMainBalance(ctx context.Context) (*temboplus.CollectionBalanceResponse, error) {
+	f.record("GetMainBalance")
+	if f.GetMainBalanceFunc != nil {
+		return f.GetMainBalanceFunc(ctx)
+	}
+	return &temboplus.CollectionBalanceResponse{AccountNo: MainAccountNo, AccountStatus: "ACTIVE"}, nil
+}
+
+// GetWalletBalance records the call and returns a zero balance by default
+func (f *FakeClient) GetWalletBalance(ctx context.Context, accountNo string) (*temboplus.CollectionBalanceResponse, error) {
+	f.record("GetWalletBalance", accountNo)
+	if f.GetWalletBalanceFunc != nil {
+		return f.GetWalletBalanceFunc(ctx, accountNo)
+	}
+	return &temboplus.CollectionBalanceResponse{AccountNo: accountNo, AccountStatus: "ACTIVE"}, nil
+}
+
+// GetCollectionStatement records the call and returns no entries by default
+func (f *FakeClient) GetCollectionStatement(ctx context.Context, req temboplus.CollectionStatementRequest) ([]temboplus.CollectionStatementEntry, error) {
+	f.record("GetCollectionStatement", req)
+	if f.GetCollectionStatementFunc != nil {
+		return f.GetCollectionStatementFunc(ctx, req)
+	}
+	return []temboplus.CollectionStatementEntry{}, nil
+}
+
+// GetMainStatement records the call and returns no entries by default
+func (f *FakeClient) GetMainStatement(ctx context.Context, req temboplus.CollectionStatementRequest) ([]temboplus.CollectionStatementEntry, error) {
+	f.record("GetMainStatement", req)
+	if f.GetMainStatementFunc != nil {
+		return f.GetMainStatementFunc(ctx, req)
+	}
+	return []temboplus.CollectionStatementEntry{}, nil
+}
+
+// ListWallets records the call and returns the main and collection wallets by default
+func (f *FakeClient) ListWallets(ctx context.Context) ([]temboplus.Wallet, error) {
+	f.record("ListWallets")
+	if f.ListWalletsFunc != nil {
+		return f.ListWalletsFunc(ctx)
+	}
+	return []temboplus.Wallet{{AccountNo: MainAccountNo}, {AccountNo: CollectionAccountNo}}, nil
+}
+
+// ValidateWebhook records the call and parses the payload like Client does by default
+func (f *FakeClient) ValidateWebhook(payload []byte) (*temboplus.WebhookPayload, error) {
+	f.record("ValidateWebhook", payload)
+	if f.ValidateWebhookFunc != nil {
+		return f.ValidateWebhookFunc(payload)
+	}
+	return (&temboplus.Client{}).ValidateWebhook(payload)
+}
+
+func accepted(req temboplus.PaymentStatusRequest) *temboplus.MobileMoneyCollectionResponse {
+	return &temboplus.MobileMoneyCollectionResponse{
+		StatusCode:     temboplus.StatusPaymentAccepted,
+		TransactionRef: req.TransactionRef,
+		TransactionID:  req.TransactionID,
+	}
+}
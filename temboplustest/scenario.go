@@ -0,0 +1,96 @@
+package temboplustest
+
+import (
+	"context"
+	"time"
+)
+
+// Outcome is the scripted result of a single request
+type Outcome int
+
+const (
+	// OutcomeAccept processes the request normally
+	OutcomeAccept Outcome = iota
+	// OutcomeReject answers collections and payments with PAYMENT_REJECTED
+	OutcomeReject
+	// OutcomeGenericError answers collections and payments with GENERIC_ERROR
+	OutcomeGenericError
+	// OutcomeUnauthorized answers with HTTP 401 INVALID_CREDENTIALS
+	OutcomeUnauthorized
+	// OutcomeServerError answers with HTTP 500
+	OutcomeServerError
+)
+
+// Scenario describes how the server responds to one request
+type Scenario struct {
+	Outcome Outcome
+	Delay   time.Duration // Wait before responding
+}
+
+// Common scenarios
+var (
+	Accept       = Scenario{Outcome: OutcomeAccept}
+	Reject       = Scenario{Outcome: OutcomeReject}
+	GenericError = Scenario{Outcome: OutcomeGenericError}
+	Unauthorized = Scenario{Outcome: OutcomeUnauthorized}
+	ServerError  = Scenario{Outcome: OutcomeServerError}
+)
+
+// Delay returns a scenario that accepts the request after d
+func Delay(d time.Duration) Scenario {
+	return Scenario{Outcome: OutcomeAccept, Delay: d}
+}
+
+// With returns a copy of the scenario that waits d before responding
+func (sc Scenario) With(d time.Duration) Scenario {
+	sc.Delay = d
+	return sc
+}
+
+// Script queues scenarios for the given endpoint. Each request to the
+// endpoint consumes the next scenario; once the queue is empty the
+// endpoint's default applies.
+func (s *Server) Script(endpoint string, scenarios ...Scenario) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scripts[endpoint] = append(s.scripts[endpoint], scenarios...)
+}
+
+// SetDefault sets the scenario used for an endpoint when nothing is
+// scripted. Pass an empty endpoint to set the default for all endpoints.
+func (s *Server) SetDefault(endpoint string, sc Scenario) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.defaults[endpoint] = sc
+}
+
+// Reset clears all scripted and default scenarios
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scripts = make(map[string][]Scenario)
+	s.defaults = make(map[string]Scenario)
+}
+
+// nextScenario must be called with s.mu held
+func (s *Server) nextScenario(endpoint string) Scenario {
+	if queue := s.scripts[endpoint]; len(queue) > 0 {
+		s.scripts[endpoint] = queue[1:]
+		return queue[0]
+	}
+	if sc, ok := s.defaults[endpoint]; ok {
+		return sc
+	}
+	return s.defaults[""]
+}
+
+type scenarioKey struct{}
+
+func withScenario(ctx context.Context, sc Scenario) context.Context {
+	return context.WithValue(ctx, scenarioKey{}, sc)
+}
+
+func scenarioFrom(ctx context.Context) Scenario {
+	sc, _ := ctx.Value(scenarioKey{}).(Scenario)
+	return sc
+}
@@ -0,0 +1,648 @@
+// Package temboplustest provides an in-memory fake of the TemboPlus API for
+// integration tests. It is built on net/http/httptest and implements every
+// endpoint the SDK talks to, keeps wallet balances and statements in memory,
+// lets tests script the outcome of individual calls and can deliver webhooks
+// to a callback URL. FakeClient offers a lighter, network-free alternative
+// for unit tests that depend on temboplus.API.
+package temboplustest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	temboplus "github.com/techliana/temboplus-golang-sdk"
+)
+
+// Default wallet account numbers created by NewServer
+const (
+	MainAccountNo       = "8000000001"
+	CollectionAccountNo = "8000000002"
+)
+
+// Server is a fake TemboPlus API server
+type Server struct {
+	*httptest.Server
+
+	// AccountID and SecretKey are the credentials the server accepts.
+	// When both are empty any credentials are accepted.
+	AccountID string
+	SecretKey string
+
+	// CallbackURL, when set, receives every webhook instead of the
+	// callbackUrl supplied in the request.
+	CallbackURL string
+
+	// AutoWebhook controls whether a webhook is fired automatically once a
+	// collection or payment is resolved. Default: true
+	AutoWebhook bool
+
+	// WebhookDelay is the delay before an automatic webhook is fired
+	WebhookDelay time.Duration
+
+	mu           sync.Mutex
+	wallets      map[string]*Wallet
+	order        []string
+	transactions map[string]*Transaction
+	byID         map[string]*Transaction
+	scripts      map[string][]Scenario
+	defaults     map[string]Scenario
+	requests     []RecordedRequest
+	webhookErrs  []error
+	seq          int
+	httpClient   *http.Client
+	webhooks     sync.WaitGroup
+}
+
+// Wallet is an in-memory wallet held by the fake server
+type Wallet struct {
+	AccountNo     string
+	AccountName   string
+	AccountStatus string
+	Balance       float64
+	Statement     []temboplus.CollectionStatementEntry
+}
+
+// Transaction is a collection or payment known to the fake server
+type Transaction struct {
+	Endpoint       string
+	TransactionRef string
+	TransactionID  string
+	AccountNo      string
+	MSISDN         string
+	Channel        string
+	Amount         float64
+	StatusCode     temboplus.TransactionStatus
+	CallbackURL    string
+}
+
+// RecordedRequest is a request received by the fake server
+type RecordedRequest struct {
+	Method    string
+	Path      string
+	Header    http.Header
+	Body      []byte
+	Timestamp time.Time
+}
+
+// NewServer starts a fake TemboPlus server with an empty main wallet and an
+// empty collection wallet. Call Close when done.
+func NewServer() *Server {
+	s := &Server{
+		AutoWebhook:  true,
+		wallets:      make(map[string]*Wallet),
+		transactions: make(map[string]*Transaction),
+		byID:         make(map[string]*Transaction),
+		scripts:      make(map[string][]Scenario),
+		defaults:     make(map[string]Scenario),
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+	}
+	s.AddWallet(Wallet{AccountNo: MainAccountNo, AccountName: "MAIN WALLET"})
+	s.AddWallet(Wallet{AccountNo: CollectionAccountNo, AccountName: "COLLECTION WALLET"})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(temboplus.EndpointCollection, s.handleCollection)
+	mux.HandleFunc(temboplus.EndpointCollectionStatus, s.handleStatus)
+	mux.HandleFunc(temboplus.EndpointPaymentStatus, s.handleStatus)
+	mux.HandleFunc(temboplus.EndpointPaymentWalletToMobile, s.handleWalletToMobile)
+	mux.HandleFunc(temboplus.EndpointWalletCollectionBalance, s.handleFixedBalance(CollectionAccountNo))
+	mux.HandleFunc(temboplus.EndpointWalletMainBalance, s.handleFixedBalance(MainAccountNo))
+	mux.HandleFunc(temboplus.EndpointWalletCollectionStatement, s.handleStatement(CollectionAccountNo))
+	mux.HandleFunc(temboplus.EndpointWalletMainStatement, s.handleStatement(MainAccountNo))
+	mux.HandleFunc(temboplus.EndpointWalletList, s.handleWalletList)
+	mux.HandleFunc(temboplus.EndpointWalletBalance, s.handleWalletBalance)
+
+	s.Server = httptest.NewServer(s.middleware(mux))
+	return s
+}
+
+// Close waits for pending webhooks and shuts the server down
+func (s *Server) Close() {
+	s.webhooks.Wait()
+	s.Server.Close()
+}
+
+// Client returns a temboplus.Client configured to talk to this server
+func (s *Server) Client() *temboplus.Client {
+	return temboplus.NewClient(temboplus.ClientConfig{
+		BaseURL:   s.URL,
+		AccountID: s.AccountID,
+		SecretKey: s.SecretKey,
+	})
+}
+
+// AddWallet creates or replaces a wallet
+func (s *Server) AddWallet(w Wallet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if w.AccountStatus == "" {
+		w.AccountStatus = "ACTIVE"
+	}
+	if _, ok := s.wallets[w.AccountNo]; !ok {
+		s.order = append(s.order, w.AccountNo)
+	}
+	s.wallets[w.AccountNo] = &w
+}
+
+// SetBalance sets the balance of a wallet without recording a statement entry
+func (s *Server) SetBalance(accountNo string, balance float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if w, ok := s.wallets[accountNo]; ok {
+		w.Balance = balance
+	}
+}
+
+// Credit adds funds to a wallet and records a statement entry
+func (s *Server) Credit(accountNo string, amount float64, narration string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if w, ok := s.wallets[accountNo]; ok {
+		s.post(w, amount, narration, s.nextID("DEP"))
+	}
+}
+
+// Balance returns the current balance of a wallet
+func (s *Server) Balance(accountNo string) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if w, ok := s.wallets[accountNo]; ok {
+		return w.Balance
+	}
+	return 0
+}
+
+// Transaction returns a copy of the transaction with the given reference
+func (s *Server) Transaction(ref string) (Transaction, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.transactions[ref]
+	if !ok {
+		return Transaction{}, false
+	}
+	return *t, true
+}
+
+// Requests returns all requests received so far
+func (s *Server) Requests() []RecordedRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]RecordedRequest, len(s.requests))
+	copy(out, s.requests)
+	return out
+}
+
+// WebhookErrors returns errors encountered while delivering webhooks
+func (s *Server) WebhookErrors() []error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]error, len(s.webhookErrs))
+	copy(out, s.webhookErrs)
+	return out
+}
+
+// FireWebhook delivers a webhook for a known transaction with the given
+// status. A pending transaction is resolved to that status, with the same
+// balance and statement effects as automatic resolution; a resolved one
+// keeps its status, so duplicate or conflicting webhooks can be simulated.
+func (s *Server) FireWebhook(ref string, statusCode temboplus.TransactionStatus) error {
+	s.mu.Lock()
+	t, ok := s.transactions[ref]
+	if !ok {
+		s.mu.Unlock()
+		return fmt.Errorf("unknown transactionRef: %s", ref)
+	}
+	if t.StatusCode == temboplus.StatusPendingACK {
+		s.resolve(t, statusCode)
+	}
+	url := s.callbackFor(t)
+	payload := temboplus.WebhookPayload{
+		StatusCode:     statusCode,
+		TransactionRef: t.TransactionRef,
+		TransactionID:  t.TransactionID,
+	}
+	s.mu.Unlock()
+	return s.deliver(url, payload)
+}
+
+func (s *Server) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		s.mu.Lock()
+		s.requests = append(s.requests, RecordedRequest{
+			Method:    r.Method,
+			Path:      r.URL.Path,
+			Header:    r.Header.Clone(),
+			Body:      body,
+			Timestamp: time.Now(),
+		})
+		sc := s.nextScenario(r.URL.Path)
+		s.mu.Unlock()
+
+		if sc.Delay > 0 {
+			select {
+			case <-time.After(sc.Delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if !s.authorized(r) {
+			writeAPIError(w, http.StatusUnauthorized, "INVALID_CREDENTIALS")
+			return
+		}
+		switch sc.Outcome {
+		case OutcomeUnauthorized:
+			writeAPIError(w, http.StatusUnauthorized, "INVALID_CREDENTIALS")
+			return
+		case OutcomeServerError:
+			writeAPIError(w, http.StatusInternalServerError, "INTERNAL_SERVER_ERROR")
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(withScenario(r.Context(), sc)))
+	})
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if s.AccountID == "" && s.SecretKey == "" {
+		return true
+	}
+	return r.Header.Get("x-account-id") == s.AccountID && r.Header.Get("x-secret-key") == s.SecretKey
+}
+
+func (s *Server) handleCollection(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+	var req temboplus.MobileMoneyCollectionRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.TransactionRef == "" || req.MSISDN == "" || req.Amount <= 0 {
+		writeAPIError(w, http.StatusBadRequest, "INVALID_REQUEST")
+		return
+	}
+
+	sc := scenarioFrom(r.Context())
+	s.mu.Lock()
+	t := &Transaction{
+		Endpoint:       temboplus.EndpointCollection,
+		TransactionRef: req.TransactionRef,
+		TransactionID:  s.nextID("COL"),
+		AccountNo:      CollectionAccountNo,
+		MSISDN:         req.MSISDN,
+		Channel:        req.Channel,
+		Amount:         req.Amount,
+		CallbackURL:    req.CallbackURL,
+	}
+	s.store(t)
+	final := temboplus.TransactionStatus(temboplus.StatusPaymentAccepted)
+	switch sc.Outcome {
+	case OutcomeReject:
+		t.StatusCode = temboplus.StatusPaymentRejected
+	case OutcomeGenericError:
+		t.StatusCode = temboplus.StatusGenericError
+	default:
+		t.StatusCode = temboplus.StatusPendingACK
+	}
+	resp := responseFor(t)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, resp)
+	if t.StatusCode == temboplus.StatusPendingACK {
+		s.resolveLater(t.TransactionRef, final)
+	}
+}
+
+func (s *Server) handleWalletToMobile(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+	var req temboplus.WalletToMobileRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	if req.TransactionRef == "" || req.MSISDN == "" || req.Amount <= 0 {
+		writeAPIError(w, http.StatusBadRequest, "INVALID_REQUEST")
+		return
+	}
+	s.pay(w, r, &Transaction{
+		Endpoint:       temboplus.EndpointPaymentWalletToMobile,
+		TransactionRef: req.TransactionRef,
+		AccountNo:      req.AccountNo,
+		MSISDN:         req.MSISDN,
+		Channel:        req.ServiceCode,
+		Amount:         req.Amount,
+		CallbackURL:    req.CallbackURL,
+	}, req.Narration)
+}
+
+// pay debits t.Amount from t.AccountNo, rejecting the payment when funds are
+// insufficient, and resolves it asynchronously
+func (s *Server) pay(w http.ResponseWriter, r *http.Request, t *Transaction, narration string) {
+	sc := scenarioFrom(r.Context())
+	s.mu.Lock()
+	wallet, ok := s.wallets[t.AccountNo]
+	if !ok {
+		s.mu.Unlock()
+		writeAPIError(w, http.StatusNotFound, "ACCOUNT_NOT_FOUND")
+		return
+	}
+	t.TransactionID = s.nextID("PAY")
+	s.store(t)
+	switch {
+	case sc.Outcome == OutcomeReject || wallet.Balance < t.Amount:
+		t.StatusCode = temboplus.StatusPaymentRejected
+	case sc.Outcome == OutcomeGenericError:
+		t.StatusCode = temboplus.StatusGenericError
+	default:
+		// Funds are reserved immediately, as the real service does
+		t.StatusCode = temboplus.StatusPendingACK
+		s.post(wallet, -t.Amount, narration, t.TransactionID)
+	}
+	resp := responseFor(t)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, resp)
+	if t.StatusCode == temboplus.StatusPendingACK {
+		s.resolveLater(t.TransactionRef, temboplus.StatusPaymentAccepted)
+	}
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+	var req temboplus.PaymentStatusRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	s.mu.Lock()
+	t, ok := s.transactions[req.TransactionRef]
+	if !ok {
+		t, ok = s.byID[req.TransactionID]
+	}
+	var resp temboplus.MobileMoneyCollectionResponse
+	if ok {
+		resp = responseFor(t)
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, "TRANSACTION_NOT_FOUND")
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleFixedBalance(accountNo string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireMethod(w, r, http.MethodPost) {
+			return
+		}
+		s.writeBalance(w, accountNo)
+	}
+}
+
+func (s *Server) handleWalletBalance(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodPost) {
+		return
+	}
+	var req temboplus.WalletBalanceRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	s.writeBalance(w, req.AccountNo)
+}
+
+func (s *Server) writeBalance(w http.ResponseWriter, accountNo string) {
+	s.mu.Lock()
+	wallet, ok := s.wallets[accountNo]
+	var resp temboplus.CollectionBalanceResponse
+	if ok {
+		resp = temboplus.CollectionBalanceResponse{
+			AvailableBalance: wallet.Balance,
+			CurrentBalance:   wallet.Balance,
+			AccountNo:        wallet.AccountNo,
+			AccountStatus:    wallet.AccountStatus,
+			AccountName:      wallet.AccountName,
+		}
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, "ACCOUNT_NOT_FOUND")
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleStatement(accountNo string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !requireMethod(w, r, http.MethodPost) {
+			return
+		}
+		var req temboplus.CollectionStatementRequest
+		if !decode(w, r, &req) {
+			return
+		}
+		start, err1 := time.Parse(time.DateOnly, req.StartDate)
+		end, err2 := time.Parse(time.DateOnly, req.EndDate)
+		if err1 != nil || err2 != nil || end.Before(start) {
+			writeAPIError(w, http.StatusBadRequest, "INVALID_DATE_RANGE")
+			return
+		}
+		acct := accountNo
+		if req.WalletID != "" {
+			acct = req.WalletID
+		}
+
+		s.mu.Lock()
+		wallet, ok := s.wallets[acct]
+		entries := []temboplus.CollectionStatementEntry{}
+		if ok {
+			for _, e := range wallet.Statement {
+				d, err := time.Parse(time.DateOnly, e.ValueDate)
+				if err != nil || d.Before(start) || d.After(end) {
+					continue
+				}
+				entries = append(entries, e)
+			}
+		}
+		s.mu.Unlock()
+
+		if !ok {
+			writeAPIError(w, http.StatusNotFound, "ACCOUNT_NOT_FOUND")
+			return
+		}
+		writeJSON(w, http.StatusOK, entries)
+	}
+}
+
+func (s *Server) handleWalletList(w http.ResponseWriter, r *http.Request) {
+	if !requireMethod(w, r, http.MethodGet) {
+		return
+	}
+	s.mu.Lock()
+	wallets := make([]temboplus.Wallet, 0, len(s.order))
+	for _, no := range s.order {
+		wallets = append(wallets, temboplus.Wallet{AccountNo: no})
+	}
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, wallets)
+}
+
+// resolveLater moves a pending transaction to its final status after
+// WebhookDelay, applies its side effect and fires the webhook.
+func (s *Server) resolveLater(ref string, final temboplus.TransactionStatus) {
+	s.webhooks.Add(1)
+	go func() {
+		defer s.webhooks.Done()
+		if s.WebhookDelay > 0 {
+			time.Sleep(s.WebhookDelay)
+		}
+		s.mu.Lock()
+		t, ok := s.transactions[ref]
+		if !ok || t.StatusCode != temboplus.StatusPendingACK {
+			s.mu.Unlock()
+			return
+		}
+		s.resolve(t, final)
+		url := s.callbackFor(t)
+		payload := temboplus.WebhookPayload{
+			StatusCode:     t.StatusCode,
+			TransactionRef: t.TransactionRef,
+			TransactionID:  t.TransactionID,
+		}
+		auto := s.AutoWebhook
+		s.mu.Unlock()
+
+		if auto {
+			if err := s.deliver(url, payload); err != nil {
+				s.mu.Lock()
+				s.webhookErrs = append(s.webhookErrs, err)
+				s.mu.Unlock()
+			}
+		}
+	}()
+}
+
+func (s *Server) deliver(url string, payload temboplus.WebhookPayload) error {
+	if url == "" {
+		return nil
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	resp, err := s.httpClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("webhook delivery failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook delivery failed: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// callbackFor must be called with s.mu held
+// resolve moves a pending transaction to its final status: an accepted
+// collection credits its wallet and a failed payment returns the reserved
+// funds. Must be called with s.mu held.
+func (s *Server) resolve(t *Transaction, final temboplus.TransactionStatus) {
+	t.StatusCode = final
+	w, ok := s.wallets[t.AccountNo]
+	if !ok {
+		return
+	}
+	switch {
+	case t.Endpoint == temboplus.EndpointCollection && final.IsSuccess():
+		s.post(w, t.Amount, fmt.Sprintf("Collection from %s", t.MSISDN), t.TransactionID)
+	case t.Endpoint != temboplus.EndpointCollection && final.IsFailure():
+		s.post(w, t.Amount, fmt.Sprintf("Reversal of %s", t.TransactionRef), t.TransactionID)
+	}
+}
+
+func (s *Server) callbackFor(t *Transaction) string {
+	if s.CallbackURL != "" {
+		return s.CallbackURL
+	}
+	return t.CallbackURL
+}
+
+// store must be called with s.mu held
+func (s *Server) store(t *Transaction) {
+	s.transactions[t.TransactionRef] = t
+	s.byID[t.TransactionID] = t
+}
+
+// post records a balance movement; must be called with s.mu held
+func (s *Server) post(w *Wallet, amount float64, narration, ref string) {
+	w.Balance += amount
+	now := time.Now()
+	entry := temboplus.CollectionStatementEntry{
+		AccountNo: w.AccountNo,
+		TranRefNo: ref,
+		Narration: narration,
+		TxnDate:   now.Format(time.DateTime),
+		ValueDate: now.Format(time.DateOnly),
+		Balance:   w.Balance,
+	}
+	if amount >= 0 {
+		entry.DebitOrCredit = "Credit"
+		entry.AmountCredited.Value = &amount
+	} else {
+		debit := -amount
+		entry.DebitOrCredit = "Debit"
+		entry.AmountDebited.Value = &debit
+	}
+	w.Statement = append(w.Statement, entry)
+}
+
+// nextID must be called with s.mu held
+func (s *Server) nextID(prefix string) string {
+	s.seq++
+	return fmt.Sprintf("%s%08d", prefix, s.seq)
+}
+
+func responseFor(t *Transaction) temboplus.MobileMoneyCollectionResponse {
+	return temboplus.MobileMoneyCollectionResponse{
+		StatusCode:     t.StatusCode,
+		TransactionRef: t.TransactionRef,
+		TransactionID:  t.TransactionID,
+	}
+}
+
+func requireMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method != method {
+		writeAPIError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED")
+		return false
+	}
+	return true
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "INVALID_JSON")
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, reason string) {
+	writeJSON(w, status, temboplus.APIError{StatusCode: status, Reason: reason})
+}
@@ -0,0 +1,182 @@
+package temboplustest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	temboplus "github.com/techliana/temboplus-golang-sdk"
+)
+
+func TestStatementWalletSelection(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.AddWallet(Wallet{AccountNo: "W3", AccountName: "BRANCH WALLET"})
+	srv.Credit(MainAccountNo, 1000, "Main deposit")
+	srv.Credit(CollectionAccountNo, 2000, "Collection deposit")
+	srv.Credit("W3", 3000, "Branch deposit")
+	client := srv.Client()
+	today := time.Now().Format(time.DateOnly)
+
+	tests := []struct {
+		name     string
+		main     bool // main statement endpoint, else collection
+		walletID string
+		want     string // account of the returned entries
+	}{
+		{"main", true, "", MainAccountNo},
+		{"wallet through main endpoint", true, "W3", "W3"},
+		{"main again", true, "", MainAccountNo},
+		{"collection", false, "", CollectionAccountNo},
+		{"wallet through collection endpoint", false, "W3", "W3"},
+		{"collection again", false, "", CollectionAccountNo},
+	}
+	for _, tt := range tests {
+		req := temboplus.CollectionStatementRequest{StartDate: today, EndDate: today, WalletID: tt.walletID}
+		get := client.GetCollectionStatement
+		if tt.main {
+			get = client.GetMainStatement
+		}
+		entries, err := get(context.Background(), req)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(entries) != 1 || entries[0].AccountNo != tt.want {
+			t.Errorf("%s: entries = %+v, want one entry of %s", tt.name, entries, tt.want)
+		}
+	}
+}
+
+func TestPayoutDebitsWallet(t *testing.T) {
+	tests := []struct {
+		name        string
+		balance     float64
+		scenario    Scenario
+		wantStatus  temboplus.TransactionStatus
+		wantBalance float64
+	}{
+		{"accepted", 5000, Accept, temboplus.StatusPendingACK, 3000},
+		{"insufficient funds", 1000, Accept, temboplus.StatusPaymentRejected, 1000},
+		{"scripted rejection", 5000, Reject, temboplus.StatusPaymentRejected, 5000},
+		{"scripted generic error", 5000, GenericError, temboplus.StatusGenericError, 5000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := NewServer()
+			defer srv.Close()
+			srv.AutoWebhook = false
+			srv.SetBalance(MainAccountNo, tt.balance)
+			srv.Script(temboplus.EndpointPaymentWalletToMobile, tt.scenario)
+
+			resp, _ := srv.Client().PayWalletToMobile(context.Background(), temboplus.WalletToMobileRequest{
+				CountryCode:     "TZ",
+				AccountNo:       MainAccountNo,
+				ServiceCode:     "TZ-TIGO-B2C",
+				Amount:          2000,
+				MSISDN:          "255715123456",
+				Narration:       "Payout",
+				CurrencyCode:    "TZS",
+				RecipientNames:  "Asha Juma",
+				TransactionRef:  "PAY-1",
+				TransactionDate: temboplus.FormatTransactionDate(time.Now()),
+				CallbackURL:     "https://example.com/webhooks/temboplus",
+			})
+			if resp == nil || resp.StatusCode != tt.wantStatus {
+				t.Fatalf("response = %+v, want %s", resp, tt.wantStatus)
+			}
+			if got := srv.Balance(MainAccountNo); got != tt.wantBalance {
+				t.Errorf("balance = %.2f, want %.2f", got, tt.wantBalance)
+			}
+		})
+	}
+}
+
+func TestCredentials(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.AccountID, srv.SecretKey = "account", "secret"
+
+	if _, err := srv.Client().GetMainBalance(context.Background()); err != nil {
+		t.Fatalf("valid credentials: %v", err)
+	}
+	wrong := temboplus.NewClient(temboplus.ClientConfig{BaseURL: srv.URL, AccountID: "account", SecretKey: "wrong"})
+	_, err := wrong.GetMainBalance(context.Background())
+	var apiErr temboplus.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 401 {
+		t.Fatalf("wrong credentials: error = %v, want 401", err)
+	}
+}
+
+func TestFireWebhookResolves(t *testing.T) {
+	tests := []struct {
+		name        string
+		payout      bool
+		status      temboplus.TransactionStatus
+		account     string
+		wantBalance float64
+		wantEntries int
+	}{
+		{"accepted collection", false, temboplus.StatusPaymentAccepted, CollectionAccountNo, 2000, 1},
+		{"rejected collection", false, temboplus.StatusPaymentRejected, CollectionAccountNo, 0, 0},
+		{"accepted payout", true, temboplus.StatusPaymentAccepted, MainAccountNo, 3000, 2},
+		{"rejected payout", true, temboplus.StatusPaymentRejected, MainAccountNo, 5000, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := NewServer()
+			defer srv.Close()
+			srv.AutoWebhook = false
+			srv.WebhookDelay = 100 * time.Millisecond // resolved by hand first
+			srv.Credit(MainAccountNo, 5000, "Deposit")
+			hooks := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+			defer hooks.Close()
+			client := srv.Client()
+			ctx := context.Background()
+			date := temboplus.FormatTransactionDate(time.Now())
+
+			var err error
+			if tt.payout {
+				_, err = client.PayWalletToMobile(ctx, temboplus.WalletToMobileRequest{
+					CountryCode: "TZ", AccountNo: MainAccountNo, ServiceCode: "TZ-TIGO-B2C", Amount: 2000,
+					MSISDN: "255715123456", Narration: "Payout", CurrencyCode: "TZS", RecipientNames: "Asha Juma",
+					TransactionRef: "REF-1", TransactionDate: date, CallbackURL: hooks.URL,
+				})
+			} else {
+				_, err = client.CollectFromMobileMoney(ctx, temboplus.MobileMoneyCollectionRequest{
+					MSISDN: "255715123456", Channel: temboplus.ChannelAuto, Amount: 2000, Narration: "Order",
+					TransactionRef: "REF-1", TransactionDate: date, CallbackURL: hooks.URL,
+				})
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := srv.FireWebhook("REF-1", tt.status); err != nil {
+				t.Fatal(err)
+			}
+			// The automatic resolution must not apply a second time
+			time.Sleep(150 * time.Millisecond)
+
+			if tx, _ := srv.Transaction("REF-1"); tx.StatusCode != tt.status {
+				t.Errorf("status = %s, want %s", tx.StatusCode, tt.status)
+			}
+			if got := srv.Balance(tt.account); got != tt.wantBalance {
+				t.Errorf("balance = %.2f, want %.2f", got, tt.wantBalance)
+			}
+			today := time.Now().Format(time.DateOnly)
+			get := client.GetCollectionStatement
+			if tt.account == MainAccountNo {
+				get = client.GetMainStatement
+			}
+			entries, err := get(ctx, temboplus.CollectionStatementRequest{StartDate: today, EndDate: today})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != tt.wantEntries {
+				t.Errorf("%d statement entries, want %d", len(entries), tt.wantEntries)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package temboplus
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WalletInfo combines a wallet with its metadata and balance
+type WalletInfo struct {
+	AccountNo        string  `json:"accountNo"`
+	AccountName      string  `json:"accountName"`
+	AccountStatus    string  `json:"accountStatus"`
+	AvailableBalance float64 `json:"availableBalance"`
+	CurrentBalance   float64 `json:"currentBalance"`
+	Err              error   `json:"-"`               // Set when the balance could not be fetched
+	Error            string  `json:"error,omitempty"` // Err as text, so JSON output keeps it
+}
+
+// Active reports whether the wallet's balance was fetched and its status is
+// active (or unreported)
+func (w WalletInfo) Active() bool {
+	return w.Err == nil && (w.AccountStatus == "" || strings.EqualFold(w.AccountStatus, "ACTIVE"))
+}
+
+// setErr records why the balance could not be fetched
+func (w *WalletInfo) setErr(err error) {
+	w.Err = err
+	w.Error = err.Error()
+}
+
+// WalletSelectionPolicy chooses a payout source from wallets that are
+// active and hold at least the requested amount
+type WalletSelectionPolicy func(candidates []WalletInfo, amount float64) (*WalletInfo, error)
+
+// HighestBalance selects the wallet with the largest available balance
+func HighestBalance() WalletSelectionPolicy {
+	return func(candidates []WalletInfo, amount float64) (*WalletInfo, error) {
+		if len(candidates) == 0 {
+			return nil, fmt.Errorf("no active wallet has %.2f available", amount)
+		}
+		best := candidates[0]
+		for _, w := range candidates[1:] {
+			if w.AvailableBalance > best.AvailableBalance {
+				best = w
+			}
+		}
+		return &best, nil
+	}
+}
+
+// NamedWallet selects the wallet whose account name (case-insensitive) or
+// account number equals name
+func NamedWallet(name string) WalletSelectionPolicy {
+	return func(candidates []WalletInfo, amount float64) (*WalletInfo, error) {
+		for _, w := range candidates {
+			if w.AccountNo == name || strings.EqualFold(w.AccountName, name) {
+				return &w, nil
+			}
+		}
+		return nil, fmt.Errorf("wallet %q not found, inactive or holding less than %.2f", name, amount)
+	}
+}
+
+// FirstWithFunds selects the first wallet, in ListWallets order, that holds
+// the amount
+func FirstWithFunds() WalletSelectionPolicy {
+	return func(candidates []WalletInfo, amount float64) (*WalletInfo, error) {
+		if len(candidates) == 0 {
+			return nil, fmt.Errorf("no active wallet has %.2f available", amount)
+		}
+		return &candidates[0], nil
+	}
+}
+
+// WalletsOptions configures the Wallets service
+type WalletsOptions struct {
+	Concurrency int // Maximum concurrent balance requests. Default: 4
+}
+
+// Wallets works across all wallets of the account
+type Wallets struct {
+	client API
+	opts   WalletsOptions
+}
+
+// NewWallets creates a Wallets service on top of client
+func NewWallets(client API, opts WalletsOptions) *Wallets {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+	return &Wallets{client: client, opts: opts}
+}
+
+// Wallets returns a Wallets service using this client
+func (c *Client) Wallets() *Wallets {
+	return NewWallets(c, WalletsOptions{})
+}
+
+// List enumerates wallets and fetches their balances concurrently. A failed
+// balance lookup is reported in the wallet's Err field rather than failing
+// the whole call.
+func (w *Wallets) List(ctx context.Context) ([]WalletInfo, error) {
+	wallets, err := w.client.ListWallets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]WalletInfo, len(wallets))
+	sem := make(chan struct{}, w.opts.Concurrency)
+	var wg sync.WaitGroup
+	for i, wallet := range wallets {
+		infos[i].AccountNo = wallet.AccountNo
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				infos[i].setErr(ctx.Err())
+				return
+			}
+			balance, err := w.client.GetWalletBalance(ctx, wallet.AccountNo)
+			if err != nil {
+				infos[i].setErr(err)
+				return
+			}
+			infos[i] = walletInfoFromBalance(wallet.AccountNo, balance)
+		}()
+	}
+	wg.Wait()
+	return infos, nil
+}
+
+// Get fetches the metadata and balance of a single wallet
+func (w *Wallets) Get(ctx context.Context, accountNo string) (*WalletInfo, error) {
+	balance, err := w.client.GetWalletBalance(ctx, accountNo)
+	if err != nil {
+		return nil, err
+	}
+	info := walletInfoFromBalance(accountNo, balance)
+	return &info, nil
+}
+
+// SelectSource picks the wallet to pay amount from according to policy.
+// Only active wallets holding at least amount are considered.
+func (w *Wallets) SelectSource(ctx context.Context, amount float64, policy WalletSelectionPolicy) (*WalletInfo, error) {
+	if policy == nil {
+		policy = HighestBalance()
+	}
+	infos, err := w.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []WalletInfo
+	for _, info := range infos {
+		if info.Active() && info.AvailableBalance >= amount {
+			candidates = append(candidates, info)
+		}
+	}
+	return policy(candidates, amount)
+}
+
+// TotalAvailable returns the sum of available balances of active wallets
+func (w *Wallets) TotalAvailable(ctx context.Context) (float64, error) {
+	infos, err := w.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var total float64
+	for _, info := range infos {
+		if info.Active() {
+			total += info.AvailableBalance
+		}
+	}
+	return total, nil
+}
+
+// Statement fetches the statement of a wallet through the main statement
+// endpoint with req.WalletID set to accountNo
+func (w *Wallets) Statement(ctx context.Context, accountNo string, req CollectionStatementRequest) ([]CollectionStatementEntry, error) {
+	if accountNo == "" {
+		return nil, fmt.Errorf("accountNo is required")
+	}
+	req.WalletID = accountNo
+	return w.client.GetMainStatement(ctx, req)
+}
+
+// StatementEntries streams the statement of a wallet between start and end.
+// See Client.CollectionStatementEntries.
+func (w *Wallets) StatementEntries(ctx context.Context, accountNo string, start, end time.Time, opts StatementIterOptions) iter.Seq2[CollectionStatementEntry, error] {
+	opts.WalletID = accountNo
+	return statementEntries(ctx, w.client.GetMainStatement, start, end, opts)
+}
+
+// SortByBalance sorts wallets by available balance, highest first
+func SortByBalance(infos []WalletInfo) {
+	sort.SliceStable(infos, func(a, b int) bool { return infos[a].AvailableBalance > infos[b].AvailableBalance })
+}
+
+func walletInfoFromBalance(accountNo string, b *CollectionBalanceResponse) WalletInfo {
+	info := WalletInfo{
+		AccountNo:        accountNo,
+		AccountName:      b.AccountName,
+		AccountStatus:    b.AccountStatus,
+		AvailableBalance: b.AvailableBalance,
+		CurrentBalance:   b.CurrentBalance,
+	}
+	if b.AccountNo != "" {
+		info.AccountNo = b.AccountNo
+	}
+	return info
+}
@@ -0,0 +1,44 @@
+package temboplus
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// maxWebhookBody limits the size of accepted webhook bodies
+const maxWebhookBody = 1 << 20
+
+// WebhookHandlerFunc processes a validated webhook. Returning an error makes
+// the handler answer 500 so TemboPlus retries the delivery.
+type WebhookHandlerFunc func(ctx context.Context, webhook *WebhookPayload) error
+
+// WebhookHandler returns an http.Handler that accepts TemboPlus webhook
+// callbacks, validates them with ValidateWebhook and passes them to fn
+func (c *Client) WebhookHandler(fn WebhookHandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBody))
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+
+		webhook, err := c.ValidateWebhook(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := fn(r.Context(), webhook); err != nil {
+			http.Error(w, "failed to process webhook", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}